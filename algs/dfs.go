@@ -11,16 +11,41 @@ type DFS struct {
 }
 
 func (dfs *DFS) Traverse(startingPoint int) types.Path {
+	tree := dfs.traverse(startingPoint)
+	dfs.reset()
+	return tree
+}
+
+// TraverseForest builds a spanning tree of every connected component and
+// returns the trees with the number of the component of every point.
+// Components are numbered by their smallest point.
+func (dfs *DFS) TraverseForest() ([]types.Path, []int) {
+	forest := make([]types.Path, 0)
+	components := make([]int, len(dfs.points))
+	for i := range dfs.points {
+		if dfs.points[i].State == types.STATE_BLACK {
+			continue
+		}
+		tree := dfs.traverse(i)
+		components[i] = len(forest)
+		for _, edge := range tree {
+			components[edge.Edge[0]] = len(forest)
+			components[edge.Edge[1]] = len(forest)
+		}
+		forest = append(forest, tree)
+	}
+	dfs.reset()
+	return forest, components
+}
+
+func (dfs *DFS) traverse(startingPoint int) types.Path {
 	stack := data_structs.Stack[*types.Edge]{}
 	for i := len(dfs.graph[startingPoint]) - 1; i >= 0; i-- {
-		if dfs.graph[startingPoint][i] != nil {
-			stack.Push(dfs.graph[startingPoint][i])
-		}
+		stack.Push(dfs.graph[startingPoint][i].Edge)
 	}
 	dfs.points[startingPoint].State = types.STATE_BLACK
 	tree := types.Path{}
-	for !stack.IsEmpty() {
-		edge := stack.Pop()
+	for edge, err := stack.Pop(); err == nil; edge, err = stack.Pop() {
 		if edge.State == types.STATE_BLACK {
 			continue
 		}
@@ -35,19 +60,21 @@ func (dfs *DFS) Traverse(startingPoint int) types.Path {
 		}
 		tree = append(tree, edge)
 		for i := len(dfs.graph[otherPoint]) - 1; i >= 0; i-- {
-			if nextEdge := dfs.graph[otherPoint][i]; nextEdge != nil && nextEdge.State != types.STATE_BLACK {
+			if nextEdge := dfs.graph[otherPoint][i].Edge; nextEdge.State != types.STATE_BLACK {
 				stack.Push(nextEdge)
 			}
 		}
 		dfs.points[otherPoint].State = types.STATE_BLACK
 	}
-	dfs.reset()
 	return tree
 }
 
 func (dfs *DFS) reset() {
 	for i := 0; i < len(dfs.points); i++ {
 		dfs.points[i].State = types.STATE_WHITE
+		for _, adjacency := range dfs.graph[i] {
+			adjacency.Edge.State = types.STATE_WHITE
+		}
 	}
 }
 
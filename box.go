@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bufio"
+	"cycles/types"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseBoundary reads the LAMMPS boundary style like "ppf", p stands for a
+// periodic dimension and f, s or m for a non-periodic one.
+func parseBoundary(boundary string) ([3]bool, error) {
+	periodic := [3]bool{}
+	if len(boundary) != 3 {
+		return periodic, fmt.Errorf("wrong boundary %q, expected a letter per dimension", boundary)
+	}
+	for i, style := range boundary {
+		switch style {
+		case 'p':
+			periodic[i] = true
+		case 'f', 's', 'm':
+		default:
+			return periodic, fmt.Errorf("wrong boundary style %q", style)
+		}
+	}
+	return periodic, nil
+}
+
+// parseBox reads the box bounds from the header of a LAMMPS data file.
+func parseBox(content string, periodic [3]bool) (*types.Box, error) {
+	box := &types.Box{Periodic: periodic}
+	found := [3]bool{}
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "Masses" || fields[0] == "Atoms" {
+			break
+		}
+		if len(fields) == 6 && fields[3] == "xy" {
+			return nil, errors.New("triclinic boxes are not supported")
+		}
+		if len(fields) != 4 {
+			continue
+		}
+		for dimension, name := range []string{"x", "y", "z"} {
+			if fields[2] != name+"lo" || fields[3] != name+"hi" {
+				continue
+			}
+			lo, err := strconv.ParseFloat(fields[0], 64)
+			if err != nil {
+				return nil, err
+			}
+			hi, err := strconv.ParseFloat(fields[1], 64)
+			if err != nil {
+				return nil, err
+			}
+			box.Lo[dimension] = lo
+			box.Hi[dimension] = hi
+			found[dimension] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for i, dimension := range "xyz" {
+		if periodic[i] && !found[i] {
+			return nil, fmt.Errorf("could not find the %clo %chi line of the periodic box", dimension, dimension)
+		}
+	}
+	return box, nil
+}
@@ -6,8 +6,11 @@ import (
 	"cycles/data_structs"
 	"cycles/types"
 	"encoding/json"
+	"fmt"
 	"math"
 	"slices"
+	"sync"
+	"sync/atomic"
 
 	lammps_structs "github.com/Ivanestver/lammps-file-parser/structs"
 )
@@ -16,63 +19,248 @@ type Data struct {
 	points               []*types.Point
 	edges                []*types.Edge
 	graph                data_structs.Graph
+	components           []int
+	componentsCount      int
 	spanningTreeEdges    []*types.Edge
 	nonSpanningTreeEdges []*types.Edge
 	supportVectors       []types.SupportVector
+	weights              []float64
 }
 
-type Cycle []*types.Point
+// CalculateCycles finds the minimum cycle basis of a LammpsStruct serialized
+// to JSON.
+func CalculateCycles(jsonObj string, options Options) (*Result, error) {
+	graphJson, err := ParseGraphJson(jsonObj)
+	if err != nil {
+		return nil, err
+	}
+	return CalculateCyclesOfGraph(graphJson, options)
+}
 
-func CalculateCycles(jsonObj string) ([]Cycle, error) {
-	// 1. Initialization step
-	data, err := initialize(jsonObj)
+// CalculateCyclesOfGraph finds the minimum cycle basis of a graph. The edges
+// are validated first, the graph is built from them when it is missing.
+func CalculateCyclesOfGraph(graphJson *GraphJson, options Options) (*Result, error) {
+	options, err := options.withMode()
 	if err != nil {
 		return nil, err
 	}
+	if graphJson, err = graphJson.withGraph(); err != nil {
+		return nil, err
+	}
+	// 1. Initialization step
+	data := makeData(graphJson)
 
 	// 2. Iteration step
-	supportVectorSize := len(data.edges)
-	supportVectors := getSupportVectors(data.nonSpanningTreeEdges, len(data.points), data.edges)
-	cyclesOfEdges := make([][]*types.Edge, len(supportVectors))
-	shift := len(data.points)
-	for k := 0; k < len(cyclesOfEdges); k++ {
-		supportVector := supportVectors[k]
-		doubledGraph := createDoubledGraph(data.graph, data.edges, supportVector)
-		for pointNumber := range data.points {
-			cycle := getCycle(doubledGraph, pointNumber, shift+pointNumber)
-			if cyclesOfEdges[k] == nil || len(cyclesOfEdges[k]) > len(cycle) {
-				cyclesOfEdges[k] = cycle
-			}
+	cyclesOfEdges, err := calculateCyclesOfEdges(data, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return makeResult(data, cyclesOfEdges, options), nil
+}
+
+// makeResult makes the cycles of the mode out of the minimum cycle basis.
+// The total weight is the one of the basis whatever the mode is. The basis
+// cycles the search has cut are nil.
+func makeResult(data *Data, basis [][]*types.Edge, options Options) *Result {
+	result := &Result{
+		CyclomaticNumber: len(data.supportVectors),
+		ComponentsCount:  data.componentsCount,
+	}
+	for i, cycle := range basis {
+		if cycle == nil || !options.fits(cycle) {
+			result.Cut = append(result.Cut, i)
+		} else {
+			result.TotalWeight += getCycleWeight(data.weights, cycle)
 		}
-		for j := k + 1; j < len(supportVectors); j++ {
-			cycleSupportVector := turnCycleIntoSupportVector(cyclesOfEdges[k], supportVectorSize)
-			if testScalarMultiplication(cycleSupportVector, supportVectors[j]) {
-				supportVectors[j] = supportVectors[j].XOR(supportVectors[k])
+	}
+	var cycles []Cycle
+	switch options.Mode {
+	case ModeRelevant, ModeESSR, ModeURF:
+		// Only the cycles larger than all the ones that fit are nil, and only
+		// when all the edges weigh the same, so the relevant cycles that fit
+		// are the same
+		found := slices.DeleteFunc(slices.Clone(basis), func(cycle []*types.Edge) bool { return cycle == nil })
+		cycles = makeFamilyCycles(data, getRelevantFamilies(data, found), options)
+	default:
+		cycles = makeCycles(data, basis, options)
+	}
+	result.Cycles = filterCycles(cycles, options)
+	return result
+}
+
+// NewResult makes the result of the cycles another algorithm has found in the
+// graph, the total weight is the one of all these cycles. The mode of the
+// options is not used.
+func NewResult(graphJson *GraphJson, cyclesOfEdges [][]*types.Edge, options Options) (*Result, error) {
+	graphJson, err := graphJson.withGraph()
+	if err != nil {
+		return nil, err
+	}
+	data := makeData(graphJson)
+	weights, err := getEdgeWeights(options.Weight, data.points, data.edges, options.Box)
+	if err != nil {
+		return nil, err
+	}
+	data.weights = weights
+	result := &Result{
+		CyclomaticNumber: len(data.supportVectors),
+		ComponentsCount:  data.componentsCount,
+	}
+	for _, cycle := range cyclesOfEdges {
+		result.TotalWeight += getCycleWeight(data.weights, cycle)
+	}
+	result.Cycles = filterCycles(makeCycles(data, cyclesOfEdges, options), options)
+	return result, nil
+}
+
+// filterCycles leaves out the cycles larger than MaxRingSize and the winding
+// ones when they are excluded
+func filterCycles(cycles []Cycle, options Options) []Cycle {
+	return slices.DeleteFunc(cycles, func(cycle Cycle) bool {
+		return !options.fits(cycle.Edges) || (options.ExcludeWinding && cycle.IsWinding())
+	})
+}
+
+// makeFamilyCycles makes the cycles of the relevant families telling their
+// unique ring families. Only the first cycle of every unique ring family is
+// kept in ModeURF.
+func makeFamilyCycles(data *Data, families []*family, options Options) []Cycle {
+	urfs := getUniqueRingFamilies(families, len(data.edges))
+	urfSizes := make([]int, 0)
+	for i, f := range families {
+		if urfs[i] == len(urfSizes) {
+			urfSizes = append(urfSizes, 0)
+		}
+		urfSizes[urfs[i]] += len(f.cycles)
+	}
+	cyclesOfEdges := make([][]*types.Edge, 0)
+	cyclesOfURFs := make([]int, 0)
+	for i, f := range families {
+		if options.Mode == ModeURF {
+			if urfs[i] == len(cyclesOfURFs) {
+				cyclesOfEdges = append(cyclesOfEdges, f.cycles[0])
+				cyclesOfURFs = append(cyclesOfURFs, urfs[i])
 			}
+			continue
 		}
+		for _, cycle := range f.cycles {
+			cyclesOfEdges = append(cyclesOfEdges, cycle)
+			cyclesOfURFs = append(cyclesOfURFs, urfs[i])
+		}
+	}
+	cycles := makeCycles(data, cyclesOfEdges, options)
+	for i := range cycles {
+		cycles[i].Family = cyclesOfURFs[i]
+		cycles[i].FamilySize = urfSizes[cyclesOfURFs[i]]
 	}
+	return cycles
+}
 
-	cycles := make([]Cycle, len(cyclesOfEdges))
+func makeCycles(data *Data, cyclesOfEdges [][]*types.Edge, options Options) []Cycle {
+	cycles := make([]Cycle, 0, len(cyclesOfEdges))
 	for i, cycleOfEdges := range cyclesOfEdges {
-		cycles[i] = turnCyclesOfEdgesIntoCycle(cycleOfEdges, data.points)
+		if cycleOfEdges == nil {
+			continue
+		}
+		cycle := turnCyclesOfEdgesIntoCycle(cycleOfEdges, data.points)
+		cycle.Index = i
+		cycle.Edges = cycleOfEdges
+		cycle.Incidence = turnCycleIntoSupportVector(cycleOfEdges, len(data.edges))
+		cycle.Weight = getCycleWeight(data.weights, cycleOfEdges)
+		cycle.Component = data.components[cycleOfEdges[0].Edge[0]]
+		cycle.Coordinates = getUnwrappedCoordinates(cycle.Points, options.Box)
+		cycle.Winding = getWinding(cycle.Points, cycle.Coordinates, options.Box)
+		cycles = append(cycles, cycle)
 	}
-
-	return cycles, nil
+	return cycles
 }
 
-func initialize(jsonObj string) (*Data, error) {
-	graphJson, err := parseJson(jsonObj)
+func calculateCyclesOfEdges(data *Data, options Options) ([][]*types.Edge, error) {
+	weights, err := getEdgeWeights(options.Weight, data.points, data.edges, options.Box)
 	if err != nil {
 		return nil, err
 	}
-	// 1. Get a random spanning tree
+	data.weights = weights
+	maxSize := options.getMaxSize(data.edges, weights)
+	if options.Algorithm == AlgorithmHorton {
+		// The cycles larger than the maximum size are the last ones
+		cyclesOfEdges := getHortonCycles(data, math.Inf(1), maxSize)
+		return append(cyclesOfEdges, make([][]*types.Edge, len(data.supportVectors)-len(cyclesOfEdges))...), nil
+	}
+	return extendCyclesOfEdges(data, data.supportVectors, nil, maxSize, options.getWorkers())
+}
+
+// extendCyclesOfEdges runs the iterations over the support vectors taking the
+// fixed cycles as the first ones. The result is minimal as long as the fixed
+// cycles are a part of a minimum cycle basis.
+//
+// Only the cycles of at most maxSize edges are looked for when it is
+// positive, the cycle is left nil when every such cycle is orthogonal to the
+// support vector. It is replaced by the fundamental cycle of an edge of the
+// support vector when the support vectors are updated, the support vectors
+// have no spanning tree edges, so the edge is enough. The fundamental cycle
+// is larger than maxSize too, so the cycles that are found are the lightest
+// independent cycles among the ones of at most maxSize edges. They are the
+// cycles of a minimum cycle basis that fit only when all the edges weigh the
+// same, maxSize must not be positive otherwise.
+func extendCyclesOfEdges(data *Data, supportVectors []types.SupportVector, fixed [][]*types.Edge, maxSize int, workers int) ([][]*types.Edge, error) {
+	supportVectorSize := len(data.edges)
+	cyclesOfEdges := make([][]*types.Edge, len(supportVectors))
+	searches := make([]*cycleSearch, workers)
+	for k := 0; k < len(cyclesOfEdges); k++ {
+		if k < len(fixed) {
+			// The fixed cycle takes the place of the shortest one, so it needs
+			// a support vector it is not orthogonal to.
+			picked, err := pickSupportVector(turnCycleIntoSupportVector(fixed[k], supportVectorSize), supportVectors[k:])
+			if err != nil {
+				return nil, err
+			}
+			if !picked {
+				return nil, fmt.Errorf("the cycle %d depends on the previous ones", k)
+			}
+			cyclesOfEdges[k] = fixed[k]
+		} else {
+			supportVector := supportVectors[k]
+			doubledGraph := data_structs.NewDoubledGraph(data.graph, supportVector)
+			// The shortest odd cycle passes through an edge of the support
+			// vector, so it is enough to start from one end of each such edge.
+			sources := getCycleSources(data.edges, supportVector)
+			cyclesOfEdges[k] = getShortestCycle(searches, doubledGraph, data.weights, sources, math.Inf(1), maxSize)
+		}
+		if cyclesOfEdges[k] == nil {
+			edge := supportVectors[k].GetFirst()
+			for j := k + 1; j < len(supportVectors); j++ {
+				if supportVectors[j].Test(edge) {
+					supportVectors[j].XORInPlace(supportVectors[k])
+				}
+			}
+			continue
+		}
+		cycleSupportVector := turnCycleIntoSupportVector(cyclesOfEdges[k], supportVectorSize)
+		for j := k + 1; j < len(supportVectors); j++ {
+			isOdd, err := testScalarMultiplication(cycleSupportVector, supportVectors[j])
+			if err != nil {
+				return nil, err
+			}
+			if isOdd {
+				supportVectors[j].XORInPlace(supportVectors[k])
+			}
+		}
+	}
+	return cyclesOfEdges, nil
+}
+
+func makeData(graphJson *GraphJson) *Data {
+	// 1. Get a random spanning forest, a tree per connected component
 	dfs := algs.MakeDFS(graphJson.Points, graphJson.Graph)
-	spanningTree := dfs.Traverse(0)
-	// 2. Get all the edges that are not in the spanning tree
+	forest, components := dfs.TraverseForest()
+	spanningTree := slices.Concat(forest...)
+	// 2. Get all the edges that are not in the spanning forest
 	nonSpanningTreeEdges := getNonSpanningTreeEdges(spanningTree, graphJson.Edges)
-	// 3. Get support vectors
-	supportVectors := getSupportVectors(nonSpanningTreeEdges, len(graphJson.Points), graphJson.Edges)
-	return &Data{graphJson.Points, graphJson.Edges, graphJson.Graph, spanningTree, nonSpanningTreeEdges, supportVectors}, nil
+	// 3. Get support vectors, there are |E| - |V| + c of them
+	supportVectors := getSupportVectors(nonSpanningTreeEdges, len(graphJson.Edges))
+	return &Data{graphJson.Points, graphJson.Edges, graphJson.Graph, components, len(forest), spanningTree, nonSpanningTreeEdges, supportVectors, nil}
 }
 
 func MakeGraphSmall() *GraphJson {
@@ -96,123 +284,245 @@ func MakeGraphSmall() *GraphJson {
 		{Number: 4, Edge: [2]int{0, 2}},
 	}
 
-	graph := data_structs.Graph{
-		[]*types.Edge{nil, edges[0], edges[4], edges[3]},
-		[]*types.Edge{edges[0], nil, edges[1], nil},
-		[]*types.Edge{edges[4], edges[1], nil, edges[2]},
-		[]*types.Edge{edges[3], nil, edges[2], nil},
-	}
+	graph := data_structs.NewGraph(len(points))
+	graph.Connect(0, 1, edges[0])
+	graph.Connect(0, 2, edges[4])
+	graph.Connect(0, 3, edges[3])
+	graph.Connect(1, 2, edges[1])
+	graph.Connect(2, 3, edges[2])
 	return NewGraphJson(points, edges, graph)
 }
 
-func parseJson(jsonObj string) (*GraphJson, error) {
+// ParseGraphJson builds the graph of a LammpsStruct serialized to JSON. The
+// atom and bond IDs must go from 1 to their numbers, the errors of the atoms
+// and the bonds are *GraphError.
+func ParseGraphJson(jsonObj string) (*GraphJson, error) {
 	var v lammps_structs.LammpsStruct
-	if err := json.Unmarshal([]byte(jsonObj), &v); err == nil {
-		graphJson := NewGraphJson(make([]*types.Point, len(v.Atoms)), make([]*types.Edge, len(v.Bonds)), make(data_structs.Graph, len(v.Atoms)))
-		for i, atom := range v.Atoms {
-			graphJson.Points[i] = types.NewPoint(i, atom.X, atom.Y, atom.Z)
+	if err := json.Unmarshal([]byte(jsonObj), &v); err != nil {
+		return nil, err
+	}
+	points := make([]*types.Point, len(v.Atoms))
+	for _, atom := range v.Atoms {
+		if atom.AtomID < 1 || atom.AtomID > len(points) {
+			return nil, &GraphError{Kind: ErrOutOfRange, Bond: NoID, Atom: atom.AtomID}
 		}
-		for i, bond := range v.Bonds {
-			graphJson.Edges[i] = &types.Edge{
-				Number: bond.BondID - 1,
-				Edge:   [2]int{bond.Ends[0].AtomID - 1, bond.Ends[1].AtomID - 1},
+		if points[atom.AtomID-1] != nil {
+			return nil, &GraphError{Kind: ErrDuplicateAtom, Bond: NoID, Atom: atom.AtomID}
+		}
+		points[atom.AtomID-1] = types.NewPoint(atom.AtomID-1, atom.X, atom.Y, atom.Z)
+	}
+	edges := make([]*types.Edge, len(v.Bonds))
+	for _, bond := range v.Bonds {
+		if bond.BondID < 1 || bond.BondID > len(edges) {
+			return nil, &GraphError{Kind: ErrOutOfRange, Bond: bond.BondID, Atom: NoID}
+		}
+		if edges[bond.BondID-1] != nil {
+			return nil, &GraphError{Kind: ErrDuplicateBond, Bond: bond.BondID, Atom: NoID}
+		}
+		edge := &types.Edge{Number: bond.BondID - 1, Type: bond.ConnectionType}
+		for i, end := range bond.Ends {
+			if end == nil {
+				return nil, &GraphError{Kind: ErrDanglingAtom, Bond: bond.BondID, Atom: NoID}
 			}
+			edge.Edge[i] = end.AtomID - 1
 		}
-		graphJson.Graph = makeGraph(graphJson.Edges, len(graphJson.Points))
-		return graphJson, nil
-	} else {
-		return nil, err
+		edges[bond.BondID-1] = edge
 	}
+	return NewGraphJson(points, edges, nil).withGraph()
 }
 
 func makeGraph(edges []*types.Edge, pointCount int) data_structs.Graph {
-	graph := make(data_structs.Graph, pointCount)
-	for i := 0; i < len(graph); i++ {
-		graph[i] = make([]*types.Edge, len(graph))
-	}
-	for i, edge := range edges {
-		graph[edge.Edge[0]][edge.Edge[1]] = edges[i]
-		graph[edge.Edge[1]][edge.Edge[0]] = edges[i]
+	graph := data_structs.NewGraph(pointCount)
+	for _, edge := range edges {
+		if edge != nil && min(edge.Edge[0], edge.Edge[1]) >= 0 && max(edge.Edge[0], edge.Edge[1]) < pointCount {
+			graph.Connect(edge.Edge[0], edge.Edge[1], edge)
+		}
 	}
 	return graph
 }
 
+// getNonSpanningTreeEdges relies on the numbers of the edges being different,
+// see GraphJson.Validate.
 func getNonSpanningTreeEdges(spanningTreeEdges types.Path, edges []*types.Edge) []*types.Edge {
+	inSpanningTree := make([]bool, len(edges))
+	for _, edge := range spanningTreeEdges {
+		inSpanningTree[edge.Number] = true
+	}
 	nonSpanningTreeEdges := make([]*types.Edge, 0)
 	for _, edge := range edges {
-		if !slices.ContainsFunc(spanningTreeEdges, func(e *types.Edge) bool {
-			return e.Equals(edge)
-		}) {
+		if edge != nil && !inSpanningTree[edge.Number] {
 			nonSpanningTreeEdges = append(nonSpanningTreeEdges, edge)
 		}
 	}
 	return nonSpanningTreeEdges
 }
 
-func getSupportVectors(nonSpanningTreeEdges []*types.Edge, pointsCount int, edges []*types.Edge) []types.SupportVector {
-	edgesCount := len(edges)
-	supportVectorsCount := edgesCount - (pointsCount - 1)
-	supportVectors := make([]types.SupportVector, supportVectorsCount)
-	i := 0
-	for ; i < len(nonSpanningTreeEdges); i++ {
-		supportVectors[i] = make(types.SupportVector, edgesCount)
-		supportVectors[i][nonSpanningTreeEdges[i].Number] = 1
-	}
-	edgeI := len(edges) - 1
-	for ; i < supportVectorsCount; i++ {
-		supportVectors[i] = make(types.SupportVector, edgesCount)
-		supportVectors[i][edges[edgeI].Number] = 1
-		edgeI--
+func getSupportVectors(nonSpanningTreeEdges []*types.Edge, edgesCount int) []types.SupportVector {
+	supportVectors := make([]types.SupportVector, len(nonSpanningTreeEdges))
+	for i, edge := range nonSpanningTreeEdges {
+		supportVectors[i] = types.NewSupportVector(edgesCount)
+		supportVectors[i].Set(edge.Number)
 	}
 	return supportVectors
 }
 
-func createDoubledGraph(originGraph data_structs.Graph, edges []*types.Edge, supportVector types.SupportVector) data_structs.Graph {
-	originSize := len(originGraph)
-	size := originSize * 2
-	doubledGraph := make(data_structs.Graph, size)
-	for i := 0; i < len(doubledGraph); i++ {
-		doubledGraph[i] = make([]*types.Edge, size)
+// pickSupportVector moves a support vector the cycle is not orthogonal to to
+// the front.
+func pickSupportVector(cycleSupportVector types.SupportVector, supportVectors []types.SupportVector) (bool, error) {
+	for j := range supportVectors {
+		isOdd, err := testScalarMultiplication(cycleSupportVector, supportVectors[j])
+		if err != nil {
+			return false, err
+		}
+		if isOdd {
+			supportVectors[0], supportVectors[j] = supportVectors[j], supportVectors[0]
+			return true, nil
+		}
 	}
+	return false, nil
+}
+
+func getCycleSources(edges []*types.Edge, supportVector types.SupportVector) []int {
+	sources := make([]int, 0)
 	for _, edge := range edges {
-		if supportVector[edge.Number] == 0 { // in the spanning tree{
-			x := edge.Edge[0]
-			y := edge.Edge[1]
-			doubledGraph[x][y] = originGraph[x][y]
-			doubledGraph[y][x] = originGraph[y][x]
-			doubledGraph[originSize+x][originSize+y] = originGraph[x][y]
-			doubledGraph[originSize+y][originSize+x] = originGraph[y][x]
-		} else {
-			x := edge.Edge[0]
-			y := edge.Edge[1]
-			doubledGraph[x][originSize+y] = originGraph[x][y]
-			doubledGraph[originSize+y][x] = originGraph[y][x]
-			doubledGraph[originSize+x][y] = originGraph[x][y]
-			doubledGraph[y][originSize+x] = originGraph[y][x]
+		if edge != nil && supportVector.Test(edge.Number) {
+			sources = append(sources, edge.Edge[0])
+		}
+	}
+	slices.Sort(sources)
+	return slices.Compact(sources)
+}
+
+// getShortestCycle runs getCycle from every source on a pool of workers that
+// share the weight of the best cycle found so far as the search bound. Among
+// the cycles of the same weight the one of the first source wins, so the
+// result does not depend on the scheduling. It is nil when every cycle is
+// heavier than maxWeight or has more than maxSize edges. There is a worker
+// per search, the nil searches are made here and can be reused for the
+// doubled graphs of the same size.
+func getShortestCycle(searches []*cycleSearch, doubledGraph *data_structs.DoubledGraph, weights []float64, sources []int, maxWeight float64, maxSize int) []*types.Edge {
+	cycles := make([][]*types.Edge, len(sources))
+	cycleWeights := make([]float64, len(sources))
+	var bound atomic.Uint64
+	bound.Store(math.Float64bits(maxWeight))
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for worker := range min(len(searches), len(sources)) {
+		if searches[worker] == nil {
+			searches[worker] = newCycleSearch(doubledGraph.Len())
+		}
+		search := searches[worker]
+		wg.Go(func() {
+			for i := int(next.Add(1) - 1); i < len(sources); i = int(next.Add(1) - 1) {
+				cycles[i], cycleWeights[i] = search.getCycle(doubledGraph, weights, sources[i], math.Float64frombits(bound.Load()), maxSize)
+				if cycles[i] != nil {
+					storeMinWeight(&bound, cycleWeights[i])
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	best := -1
+	for i := range cycles {
+		if cycles[i] != nil && (best == -1 || cycleWeights[i] < cycleWeights[best]) {
+			best = i
 		}
 	}
-	return doubledGraph
+	if best == -1 {
+		return nil
+	}
+	return cycles[best]
+}
+
+// storeMinWeight relies on non-negative floats being ordered as their bits.
+func storeMinWeight(bound *atomic.Uint64, weight float64) {
+	bits := math.Float64bits(weight)
+	for {
+		old := bound.Load()
+		if bits >= old || bound.CompareAndSwap(old, bits) {
+			return
+		}
+	}
+}
+
+// cycleSearch keeps the distances of getCycle between the searches of a
+// worker, so that a search resets only the points the previous one has
+// reached instead of allocating them all.
+type cycleSearch struct {
+	lengths []float64
+	prev    []data_structs.Adjacency
+	touched []int
+	pq      data_structs.PriorityQueue
+}
+
+func newCycleSearch(pointsCount int) *cycleSearch {
+	search := &cycleSearch{
+		lengths: make([]float64, pointsCount),
+		prev:    make([]data_structs.Adjacency, pointsCount),
+	}
+	for i := range search.lengths {
+		search.lengths[i] = math.Inf(1)
+		search.prev[i].Point = -1
+	}
+	return search
+}
+
+func (search *cycleSearch) reset() {
+	for _, point := range search.touched {
+		search.lengths[point] = math.Inf(1)
+		search.prev[point] = data_structs.Adjacency{Point: -1}
+	}
+	search.touched = search.touched[:0]
+	search.pq = search.pq[:0]
 }
 
-func getCycle(doubledGraph data_structs.Graph, startingPoint, finishingPoint int) []*types.Edge {
-	lengths := make([]float64, len(doubledGraph))
-	prev := make([]int, len(doubledGraph))
-	for i := range lengths {
-		lengths[i] = math.MaxFloat64
-		prev[i] = -1
+// getCycle returns the shortest path from startingPoint to its twin, that is
+// the shortest closed walk through startingPoint that crosses the layers an
+// odd number of times, provided that it is not heavier than bound. The
+// weights of the edges are indexed by their numbers. The doubled graph is
+// symmetric, so the distances to the twins of the reached points are the
+// distances of the backward search, and a single Dijkstra grows both halves
+// of the walk until they meet in the middle. The walk has at most maxSize
+// edges when it is positive.
+func (search *cycleSearch) getCycle(doubledGraph *data_structs.DoubledGraph, weights []float64, startingPoint int, bound float64, maxSize int) ([]*types.Edge, float64) {
+	if maxSize > 0 {
+		return getSmallCycle(doubledGraph, weights, startingPoint, bound, maxSize)
 	}
+	search.reset()
+	lengths, prev := search.lengths, search.prev
 	lengths[startingPoint] = 0
-	pq := &data_structs.PriorityQueue{data_structs.PQItem{Dist: 0, Num: startingPoint}}
-	heap.Init(pq)
+	search.touched = append(search.touched, startingPoint)
+	bestWeight := bound
+	meetingPoint := -1
+	var meetingAdjacency data_structs.Adjacency
+	pq := &search.pq
+	heap.Push(pq, data_structs.PQItem{Dist: 0, Num: startingPoint})
 	for pq.Len() > 0 {
 		it := heap.Pop(pq).(data_structs.PQItem)
-		for i, edge := range doubledGraph[it.Num] {
-			if edge == nil {
-				continue
+		if it.Dist > lengths[it.Num] {
+			continue
+		}
+		if 2*it.Dist > bestWeight {
+			break
+		}
+		for adjacency := range doubledGraph.Neighbours(it.Num) {
+			i := adjacency.Point
+			newDist := it.Dist + weights[adjacency.Edge.Number]
+			if twinDist := lengths[doubledGraph.GetTwin(i)]; !math.IsInf(twinDist, 1) {
+				weight := newDist + twinDist
+				if weight < bestWeight || (meetingPoint == -1 && weight <= bestWeight) {
+					bestWeight = weight
+					meetingPoint = it.Num
+					meetingAdjacency = adjacency
+				}
 			}
-			if newDist := it.Dist + edge.Len(); newDist < lengths[i] {
+			if newDist < lengths[i] {
+				if math.IsInf(lengths[i], 1) {
+					search.touched = append(search.touched, i)
+				}
 				lengths[i] = newDist
-				prev[i] = it.Num
+				prev[i] = data_structs.Adjacency{Point: it.Num, Edge: adjacency.Edge}
 				heap.Push(pq, data_structs.PQItem{
 					Dist: newDist,
 					Num:  i,
@@ -220,38 +530,121 @@ func getCycle(doubledGraph data_structs.Graph, startingPoint, finishingPoint int
 			}
 		}
 	}
-	curr := finishingPoint
-	cycle := make([]*types.Edge, 0)
-	for curr != startingPoint {
-		prevPoint := prev[curr]
-		cycle = append([]*types.Edge{doubledGraph[prevPoint][curr]}, cycle...)
-		curr = prevPoint
+	if meetingPoint == -1 {
+		return nil, math.Inf(1)
 	}
-	return cycle
+	cycle := getPath(prev, startingPoint, meetingPoint)
+	cycle = append(cycle, meetingAdjacency.Edge)
+	backPath := getPath(prev, startingPoint, doubledGraph.GetTwin(meetingAdjacency.Point))
+	slices.Reverse(backPath)
+	return append(cycle, backPath...), bestWeight
 }
 
-func turnCycleIntoSupportVector(cycle []*types.Edge, cycleSize int) types.SupportVector {
-	cycleSupportVector := make(types.SupportVector, cycleSize)
-	for i := range cycleSupportVector {
-		if slices.ContainsFunc(cycle, func(edge *types.Edge) bool {
-			return edge.Number == i
-		}) {
-			cycleSupportVector[i] = 1
+// smallCycleLabel is the lightest walk of getSmallCycle to a point by a number
+// of edges, prev is the state the walk comes from
+type smallCycleLabel struct {
+	dist float64
+	prev int
+	edge *types.Edge
+}
+
+// getSmallCycle is getCycle over the walks of at most maxSize edges. The
+// lightest walk to a point may have too many edges while a heavier one fits,
+// so the walks are labelled by their points and their numbers of edges, the
+// state p*maxSize+h is the point p reached by h edges. A walk is dropped as
+// soon as it can not be closed within maxSize edges and a label is dropped
+// when a walk to the point with fewer edges is not heavier. Only the points
+// close to startingPoint are reached, so the labels are kept in a map.
+func getSmallCycle(doubledGraph *data_structs.DoubledGraph, weights []float64, startingPoint int, bound float64, maxSize int) ([]*types.Edge, float64) {
+	labels := map[int]smallCycleLabel{startingPoint * maxSize: {0, -1, nil}}
+	// getDist returns the lightest walk to the point of at most hops edges
+	getDist := func(point, hops int) (float64, int) {
+		dist, state := math.Inf(1), -1
+		for h := range min(hops, maxSize-1) + 1 {
+			if label, ok := labels[point*maxSize+h]; ok && label.dist < dist {
+				dist, state = label.dist, point*maxSize+h
+			}
+		}
+		return dist, state
+	}
+	bestWeight := bound
+	meetingState, twinState := -1, -1
+	var meetingEdge *types.Edge
+	pq := &data_structs.PriorityQueue{data_structs.PQItem{Dist: 0, Num: startingPoint * maxSize}}
+	heap.Init(pq)
+	for pq.Len() > 0 {
+		it := heap.Pop(pq).(data_structs.PQItem)
+		if it.Dist > labels[it.Num].dist {
+			continue
+		}
+		if 2*it.Dist > bestWeight {
+			break
+		}
+		point, hops := it.Num/maxSize, it.Num%maxSize
+		for adjacency := range doubledGraph.Neighbours(point) {
+			i := adjacency.Point
+			newDist := it.Dist + weights[adjacency.Edge.Number]
+			// The walk to the twin closes a cycle of hops+1+h edges
+			if twinDist, state := getDist(doubledGraph.GetTwin(i), maxSize-1-hops); state != -1 {
+				weight := newDist + twinDist
+				if weight < bestWeight || (meetingState == -1 && weight <= bestWeight) {
+					bestWeight, meetingState, twinState, meetingEdge = weight, it.Num, state, adjacency.Edge
+				}
+			}
+			if hops+1 == maxSize {
+				continue
+			}
+			if dist, _ := getDist(i, hops+1); newDist < dist {
+				labels[i*maxSize+hops+1] = smallCycleLabel{newDist, it.Num, adjacency.Edge}
+				heap.Push(pq, data_structs.PQItem{Dist: newDist, Num: i*maxSize + hops + 1})
+			}
+		}
+	}
+	if meetingState == -1 {
+		return nil, math.Inf(1)
+	}
+	getStatePath := func(state int) []*types.Edge {
+		path := make([]*types.Edge, 0)
+		for label := labels[state]; label.prev != -1; label = labels[label.prev] {
+			path = append(path, label.edge)
 		}
+		slices.Reverse(path)
+		return path
+	}
+	cycle := append(getStatePath(meetingState), meetingEdge)
+	backPath := getStatePath(twinState)
+	slices.Reverse(backPath)
+	return append(cycle, backPath...), bestWeight
+}
+
+func getPath(prev []data_structs.Adjacency, startingPoint, finishingPoint int) []*types.Edge {
+	path := make([]*types.Edge, 0)
+	for curr := finishingPoint; curr != startingPoint; curr = prev[curr].Point {
+		path = append(path, prev[curr].Edge)
+	}
+	slices.Reverse(path)
+	return path
+}
+
+func turnCycleIntoSupportVector(cycle []*types.Edge, cycleSize int) types.SupportVector {
+	cycleSupportVector := types.NewSupportVector(cycleSize)
+	for _, edge := range cycle {
+		cycleSupportVector.Set(edge.Number)
 	}
 	return cycleSupportVector
 }
 
-func testScalarMultiplication(cycleSupportVector, supportVector types.SupportVector) bool {
-	return cycleSupportVector.GetScalarMultiplication(supportVector)%2 == 1
+func testScalarMultiplication(cycleSupportVector, supportVector types.SupportVector) (bool, error) {
+	product, err := cycleSupportVector.GetScalarMultiplication(supportVector)
+	return product%2 == 1, err
 }
 
 func turnCyclesOfEdgesIntoCycle(cycleOfEdges []*types.Edge, points []*types.Point) Cycle {
 	if len(cycleOfEdges) < 3 {
-		return make(Cycle, 0)
+		return Cycle{Points: make([]*types.Point, 0)}
 	}
 
-	cycle := make(Cycle, len(cycleOfEdges))
+	cycle := make([]*types.Point, len(cycleOfEdges))
 	currentPoint := 0
 	prevCommonPointNumber := intersection(cycleOfEdges[0].Edge, cycleOfEdges[1].Edge)
 	cycle[currentPoint] = points[cycleOfEdges[0].GetOtherSide(prevCommonPointNumber)]
@@ -262,7 +655,40 @@ func turnCyclesOfEdgesIntoCycle(cycleOfEdges []*types.Edge, points []*types.Poin
 		prevCommonPointNumber = intersection(cycleOfEdges[currentPoint].Edge, cycleOfEdges[currentPoint+1].Edge)
 	}
 	cycle[currentPoint] = points[prevCommonPointNumber]
-	return cycle
+	return Cycle{Points: cycle}
+}
+
+// getUnwrappedCoordinates starts from the position of the first point and
+// adds up the minimum images of the bonds along the ring.
+func getUnwrappedCoordinates(points []*types.Point, box *types.Box) []types.Vector {
+	coordinates := make([]types.Vector, len(points))
+	for i, point := range points {
+		if i == 0 {
+			coordinates[i] = point.GetPosition()
+		} else {
+			coordinates[i] = coordinates[i-1].Add(box.GetDisplacement(points[i-1], point))
+		}
+	}
+	return coordinates
+}
+
+// getWinding closes the unwrapped ring with the minimum image of its last bond
+// and counts the box lengths the ring has travelled.
+func getWinding(points []*types.Point, coordinates []types.Vector, box *types.Box) [3]int {
+	winding := [3]int{}
+	if box == nil || len(points) == 0 {
+		return winding
+	}
+	last := len(points) - 1
+	end := coordinates[last].Add(box.GetDisplacement(points[last], points[0]))
+	travelled := end.Sub(coordinates[0])
+	lengths := box.GetLengths()
+	for i := range winding {
+		if box.Periodic[i] && lengths[i] > 0 {
+			winding[i] = int(math.Round(travelled[i] / lengths[i]))
+		}
+	}
+	return winding
 }
 
 func intersection(slice1, slice2 [2]int) int {
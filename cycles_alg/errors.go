@@ -0,0 +1,50 @@
+package cycles_alg
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The kinds of the errors of the input graph, a GraphError wraps one of them,
+// so that errors.Is tells the kind.
+var (
+	ErrDuplicateBond = errors.New("duplicate bond ID")
+	ErrDuplicateAtom = errors.New("duplicate atom ID")
+	ErrDanglingAtom  = errors.New("dangling atom reference")
+	ErrSelfLoop      = errors.New("self-loop")
+	ErrOutOfRange    = errors.New("ID out of range")
+	ErrParallelBond  = errors.New("repeated atom pair")
+	ErrMissingAtom   = errors.New("missing atom")
+	ErrGraphSize     = errors.New("the graph does not have a row per atom")
+)
+
+// ErrNilGraph is returned for a nil *GraphJson
+var ErrNilGraph = errors.New("nil graph")
+
+// NoID is the ID of a GraphError that is not about a bond or an atom
+const NoID = -1
+
+// GraphError tells which bond and atom of the input graph are wrong. The IDs
+// start from 1 as in LAMMPS.
+type GraphError struct {
+	Kind error
+	Bond int
+	Atom int
+}
+
+func (err *GraphError) Error() string {
+	switch {
+	case err.Bond != NoID && err.Atom != NoID:
+		return fmt.Sprintf("bond %d, atom %d: %v", err.Bond, err.Atom, err.Kind)
+	case err.Bond != NoID:
+		return fmt.Sprintf("bond %d: %v", err.Bond, err.Kind)
+	case err.Atom != NoID:
+		return fmt.Sprintf("atom %d: %v", err.Atom, err.Kind)
+	default:
+		return err.Kind.Error()
+	}
+}
+
+func (err *GraphError) Unwrap() error {
+	return err.Kind
+}
@@ -11,6 +11,14 @@ type GraphJson struct {
 	Graph  data_structs.Graph
 }
 
+// MakeGraphJson connects the points by the edges. The points are numbered
+// from 0 and the edges refer to them by these numbers, the edges are numbered
+// from 0 too. The edges must be valid, see Validate, the ones connecting the
+// points out of the range are left out of the graph.
+func MakeGraphJson(points []*types.Point, edges []*types.Edge) *GraphJson {
+	return NewGraphJson(points, edges, makeGraph(edges, len(points)))
+}
+
 func NewGraphJson(points []*types.Point, edges []*types.Edge, graph data_structs.Graph) *GraphJson {
 	return &GraphJson{
 		Points: points,
@@ -18,3 +26,62 @@ func NewGraphJson(points []*types.Point, edges []*types.Edge, graph data_structs
 		Graph:  graph,
 	}
 }
+
+// Validate checks that there are no nil points, that the graph has a row per
+// point when it is set, that the numbers of the edges are different and less
+// than the number of the edges, the nil edges are skipped, and that every
+// edge connects two different points no other edge connects. The error is a
+// *GraphError, or ErrNilGraph.
+func (graphJson *GraphJson) Validate() error {
+	if graphJson == nil {
+		return ErrNilGraph
+	}
+	for i, point := range graphJson.Points {
+		if point == nil {
+			return &GraphError{Kind: ErrMissingAtom, Bond: NoID, Atom: i + 1}
+		}
+	}
+	if graphJson.Graph != nil && len(graphJson.Graph) != len(graphJson.Points) {
+		return &GraphError{Kind: ErrGraphSize, Bond: NoID, Atom: NoID}
+	}
+	numbers := make([]bool, len(graphJson.Edges))
+	// The graph keeps one bond of a pair of atoms
+	pairs := make(map[[2]int]bool)
+	for _, edge := range graphJson.Edges {
+		if edge == nil {
+			continue
+		}
+		if edge.Number < 0 || edge.Number >= len(graphJson.Edges) {
+			return &GraphError{Kind: ErrOutOfRange, Bond: edge.Number + 1, Atom: NoID}
+		}
+		if numbers[edge.Number] {
+			return &GraphError{Kind: ErrDuplicateBond, Bond: edge.Number + 1, Atom: NoID}
+		}
+		numbers[edge.Number] = true
+		for _, point := range edge.Edge {
+			if point < 0 || point >= len(graphJson.Points) || graphJson.Points[point] == nil {
+				return &GraphError{Kind: ErrDanglingAtom, Bond: edge.Number + 1, Atom: point + 1}
+			}
+		}
+		if edge.Edge[0] == edge.Edge[1] {
+			return &GraphError{Kind: ErrSelfLoop, Bond: edge.Number + 1, Atom: edge.Edge[0] + 1}
+		}
+		pair := [2]int{min(edge.Edge[0], edge.Edge[1]), max(edge.Edge[0], edge.Edge[1])}
+		if pairs[pair] {
+			return &GraphError{Kind: ErrParallelBond, Bond: edge.Number + 1, Atom: NoID}
+		}
+		pairs[pair] = true
+	}
+	return nil
+}
+
+// withGraph validates the edges and builds the graph when it is missing.
+func (graphJson *GraphJson) withGraph() (*GraphJson, error) {
+	if err := graphJson.Validate(); err != nil {
+		return nil, err
+	}
+	if graphJson.Graph == nil {
+		return MakeGraphJson(graphJson.Points, graphJson.Edges), nil
+	}
+	return graphJson, nil
+}
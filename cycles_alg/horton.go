@@ -0,0 +1,130 @@
+package cycles_alg
+
+import (
+	"cmp"
+	"container/heap"
+	"cycles/data_structs"
+	"cycles/types"
+	"math"
+	"slices"
+)
+
+// getHortonCycles finds a minimum cycle basis by Horton's algorithm: the
+// cycles made of the shortest paths from a point to the ends of an edge are
+// sorted by their weights and taken while they are independent. Only the
+// cycles not heavier than the bound are made, the bound must not be lighter
+// than the heaviest cycle of a minimum cycle basis to get the whole basis.
+// Otherwise the cycles are the ones of a minimum cycle basis that are not
+// heavier. Only the cycles of at most maxSize edges are made when it is
+// positive, they are the cycles of a minimum cycle basis that fit only when
+// all the edges weigh the same.
+func getHortonCycles(data *Data, bound float64, maxSize int) [][]*types.Edge {
+	candidates := getHortonCandidates(data, bound, maxSize)
+	slices.SortStableFunc(candidates, func(c1, c2 hortonCandidate) int {
+		return cmp.Compare(c1.weight, c2.weight)
+	})
+	cycles := make([][]*types.Edge, 0, len(data.supportVectors))
+	basis := data_structs.NewGF2Basis()
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		if basis.Len() == len(data.supportVectors) {
+			break
+		}
+		supportVector := turnCycleIntoSupportVector(candidate.cycle, len(data.edges))
+		key := supportVector.Key()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if basis.Add(supportVector) {
+			cycles = append(cycles, candidate.cycle)
+		}
+	}
+	return cycles
+}
+
+type hortonCandidate struct {
+	cycle  []*types.Edge
+	weight float64
+}
+
+// getHortonCandidates makes a cycle of every point and every edge whose ends
+// are reached by the shortest path tree of the point through different
+// neighbours of the point. The cycles of a minimum cycle basis are isometric,
+// so the points of the cycles not heavier than the bound are not farther
+// than half of it. The paths of maxSize-1 edges are not extended when it is
+// positive, since no cycle of at most maxSize edges closes through them.
+func getHortonCandidates(data *Data, bound float64, maxSize int) []hortonCandidate {
+	lengths := make([]float64, len(data.graph))
+	// hops are the numbers of edges of the paths
+	hops := make([]int, len(data.graph))
+	prev := make([]data_structs.Adjacency, len(data.graph))
+	// branches are the first points of the paths after the root
+	branches := make([]int, len(data.graph))
+	for i := range lengths {
+		lengths[i] = math.Inf(1)
+	}
+	touched := make([]int, 0)
+	candidates := make([]hortonCandidate, 0)
+	for root := range data.graph {
+		for _, point := range touched {
+			lengths[point] = math.Inf(1)
+		}
+		touched = append(touched[:0], root)
+		lengths[root] = 0
+		hops[root] = 0
+		prev[root] = data_structs.Adjacency{Point: -1}
+		branches[root] = root
+		settled := make([]int, 0)
+		pq := &data_structs.PriorityQueue{data_structs.PQItem{Dist: 0, Num: root}}
+		heap.Init(pq)
+		for pq.Len() > 0 {
+			it := heap.Pop(pq).(data_structs.PQItem)
+			if it.Dist > lengths[it.Num] {
+				continue
+			}
+			settled = append(settled, it.Num)
+			if maxSize > 0 && hops[it.Num]+1 >= maxSize {
+				continue
+			}
+			for _, adjacency := range data.graph[it.Num] {
+				point := adjacency.Point
+				newDist := it.Dist + data.weights[adjacency.Edge.Number]
+				if newDist >= lengths[point] || (2*newDist > bound && !data_structs.IsSameWeight(2*newDist, bound)) {
+					continue
+				}
+				if math.IsInf(lengths[point], 1) {
+					touched = append(touched, point)
+				}
+				lengths[point] = newDist
+				hops[point] = hops[it.Num] + 1
+				prev[point] = data_structs.Adjacency{Point: it.Num, Edge: adjacency.Edge}
+				branches[point] = branches[it.Num]
+				if it.Num == root {
+					branches[point] = point
+				}
+				heap.Push(pq, data_structs.PQItem{Dist: newDist, Num: point})
+			}
+		}
+		for _, point := range settled {
+			for _, adjacency := range data.graph[point] {
+				other := adjacency.Point
+				if other < point || math.IsInf(lengths[other], 1) || branches[point] == branches[other] ||
+					prev[point].Edge == adjacency.Edge || prev[other].Edge == adjacency.Edge {
+					continue
+				}
+				weight := lengths[point] + data.weights[adjacency.Edge.Number] + lengths[other]
+				if (weight > bound && !data_structs.IsSameWeight(weight, bound)) ||
+					(maxSize > 0 && hops[point]+1+hops[other] > maxSize) {
+					continue
+				}
+				cycle := getPath(prev, root, point)
+				cycle = append(cycle, adjacency.Edge)
+				backPath := getPath(prev, root, other)
+				slices.Reverse(backPath)
+				candidates = append(candidates, hortonCandidate{append(cycle, backPath...), weight})
+			}
+		}
+	}
+	return candidates
+}
@@ -0,0 +1,218 @@
+package cycles_alg
+
+import (
+	"container/heap"
+	"cycles/data_structs"
+	"cycles/types"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// Basis keeps a minimum cycle basis up to date while bonds are added and
+// removed. Only the component of the changed bond is searched again, and
+// there only the cycles the change can make heavier than necessary:
+//   - removing a bond keeps the cycles that do not pass through it,
+//   - adding a bond keeps the cycles not heavier than the shortest cycle
+//     through it.
+//
+// Both kinds of cycles are a part of a minimum cycle basis of the new graph,
+// so the iterations start from them and only the rest is looked for.
+type Basis struct {
+	graphJson *GraphJson
+	options   Options
+	data      *Data
+	weights   []float64
+	cycles    [][]*types.Edge
+	// freeNumbers are the numbers of the removed edges, they are given to the
+	// added ones
+	freeNumbers []int
+}
+
+// NewBasis finds the minimum cycle basis of a graph. The basis owns the graph
+// from now on and changes it with the bonds.
+func NewBasis(graphJson *GraphJson, options Options) (*Basis, error) {
+	options, err := options.withMode()
+	if err != nil {
+		return nil, err
+	}
+	if graphJson, err = graphJson.withGraph(); err != nil {
+		return nil, err
+	}
+	// The whole basis is kept to be updated, the large cycles are left out
+	// of the results only
+	whole := options
+	whole.MaxRingSize = 0
+	data := makeData(graphJson)
+	cyclesOfEdges, err := calculateCyclesOfEdges(data, whole)
+	if err != nil {
+		return nil, err
+	}
+	return &Basis{
+		graphJson: graphJson,
+		options:   options,
+		data:      data,
+		weights:   data.weights,
+		cycles:    cyclesOfEdges,
+	}, nil
+}
+
+// GetResult returns the cycles of the mode of the options made out of the
+// current basis.
+func (basis *Basis) GetResult() *Result {
+	return makeResult(basis.data, basis.cycles, basis.options)
+}
+
+// AddBond connects two points and returns the new edge, its number is the
+// one of a removed edge if there is any.
+func (basis *Basis) AddBond(from, to, bondType int) (*types.Edge, error) {
+	pointsCount := len(basis.graphJson.Points)
+	if from < 0 || from >= pointsCount || to < 0 || to >= pointsCount {
+		return nil, fmt.Errorf("the bond %d-%d connects points out of the range [0, %d)", from, to, pointsCount)
+	}
+	if from == to {
+		return nil, fmt.Errorf("the bond %d-%d connects the point to itself", from, to)
+	}
+	if basis.graphJson.Graph.IsConnected(from, to) {
+		return nil, fmt.Errorf("the points %d and %d are already connected", from, to)
+	}
+	edge := &types.Edge{Number: len(basis.graphJson.Edges), Edge: [2]int{from, to}, Type: bondType}
+	if len(basis.freeNumbers) > 0 {
+		edge.Number = basis.freeNumbers[len(basis.freeNumbers)-1]
+	}
+	weight, err := getEdgeWeight(basis.options.Weight, edge, basis.graphJson.Points, basis.options.Box)
+	if err != nil {
+		return nil, err
+	}
+
+	// The cycles of another component are not affected. A bond between two
+	// components merges them without adding a cycle.
+	component := basis.data.components[from]
+	sameComponent := component == basis.data.components[to]
+	bound := math.Inf(1)
+	if sameComponent {
+		bound = weight + getDistance(basis.graphJson.Graph, basis.weights, from, to)
+	}
+	if edge.Number < len(basis.graphJson.Edges) {
+		basis.freeNumbers = basis.freeNumbers[:len(basis.freeNumbers)-1]
+		basis.graphJson.Edges[edge.Number] = edge
+		basis.weights[edge.Number] = weight
+	} else {
+		basis.graphJson.Edges = append(basis.graphJson.Edges, edge)
+		basis.weights = append(basis.weights, weight)
+	}
+	basis.graphJson.Graph.Connect(from, to, edge)
+	if !sameComponent {
+		basis.update(basis.cycles)
+		return edge, nil
+	}
+	fixed := basis.cycles[:0:0]
+	for _, cycle := range basis.cycles {
+		if basis.data.components[cycle[0].Edge[0]] != component || getCycleWeight(basis.weights, cycle) <= bound {
+			fixed = append(fixed, cycle)
+		}
+	}
+	if err := basis.extend(fixed, from); err != nil {
+		return nil, err
+	}
+	return edge, nil
+}
+
+// RemoveBond disconnects two points and returns the removed edge.
+func (basis *Basis) RemoveBond(from, to int) (*types.Edge, error) {
+	pointsCount := len(basis.graphJson.Points)
+	if from < 0 || from >= pointsCount || to < 0 || to >= pointsCount {
+		return nil, fmt.Errorf("the bond %d-%d connects points out of the range [0, %d)", from, to, pointsCount)
+	}
+	edge := basis.graphJson.Graph.GetEdge(from, to)
+	if edge == nil {
+		return nil, fmt.Errorf("the points %d and %d are not connected", from, to)
+	}
+	basis.graphJson.Graph.Disconnect(from, to)
+	basis.graphJson.Edges[edge.Number] = nil
+	basis.freeNumbers = append(basis.freeNumbers, edge.Number)
+
+	fixed := basis.cycles[:0:0]
+	for _, cycle := range basis.cycles {
+		if !slices.Contains(cycle, edge) {
+			fixed = append(fixed, cycle)
+		}
+	}
+	// A bond that is not in any cycle is a bridge, removing it splits its
+	// component without removing a cycle.
+	if len(fixed) == len(basis.cycles) {
+		basis.update(fixed)
+		return edge, nil
+	}
+	if err := basis.extend(fixed, from); err != nil {
+		return nil, err
+	}
+	return edge, nil
+}
+
+// update rebuilds the spanning forest and the support vectors of the changed
+// graph and takes the cycles as the basis.
+func (basis *Basis) update(cycles [][]*types.Edge) {
+	basis.data = makeData(basis.graphJson)
+	basis.data.weights = basis.weights
+	basis.cycles = cycles
+}
+
+// extend completes the fixed cycles of the component of the point to a
+// minimum cycle basis. The fixed cycles of the other components are kept as
+// they are.
+func (basis *Basis) extend(fixed [][]*types.Edge, point int) error {
+	basis.update(nil)
+	component := basis.data.components[point]
+	cycles := make([][]*types.Edge, 0, len(basis.data.supportVectors))
+	fixedOfComponent := make([][]*types.Edge, 0)
+	for _, cycle := range fixed {
+		if basis.data.components[cycle[0].Edge[0]] == component {
+			fixedOfComponent = append(fixedOfComponent, cycle)
+		} else {
+			cycles = append(cycles, cycle)
+		}
+	}
+	supportVectors := make([]types.SupportVector, 0)
+	for _, edge := range basis.data.nonSpanningTreeEdges {
+		if basis.data.components[edge.Edge[0]] == component {
+			supportVector := types.NewSupportVector(len(basis.data.edges))
+			supportVector.Set(edge.Number)
+			supportVectors = append(supportVectors, supportVector)
+		}
+	}
+	cyclesOfComponent, err := extendCyclesOfEdges(basis.data, supportVectors, fixedOfComponent, 0, basis.options.getWorkers())
+	if err != nil {
+		return err
+	}
+	basis.cycles = append(cycles, cyclesOfComponent...)
+	return nil
+}
+
+// getDistance returns the length of the shortest path between two points.
+func getDistance(graph data_structs.Graph, weights []float64, from, to int) float64 {
+	lengths := make([]float64, len(graph))
+	for i := range lengths {
+		lengths[i] = math.Inf(1)
+	}
+	lengths[from] = 0
+	pq := &data_structs.PriorityQueue{data_structs.PQItem{Dist: 0, Num: from}}
+	heap.Init(pq)
+	for pq.Len() > 0 {
+		it := heap.Pop(pq).(data_structs.PQItem)
+		if it.Num == to {
+			return it.Dist
+		}
+		if it.Dist > lengths[it.Num] {
+			continue
+		}
+		for _, adjacency := range graph[it.Num] {
+			newDist := it.Dist + weights[adjacency.Edge.Number]
+			if newDist < lengths[adjacency.Point] {
+				lengths[adjacency.Point] = newDist
+				heap.Push(pq, data_structs.PQItem{Dist: newDist, Num: adjacency.Point})
+			}
+		}
+	}
+	return math.Inf(1)
+}
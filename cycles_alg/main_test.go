@@ -1,12 +1,22 @@
 package cycles_alg
 
 import (
+	"cmp"
 	"cycles/algs"
 	"cycles/data_structs"
 	"cycles/types"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
 	"slices"
 	"testing"
+
+	lammps_structs "github.com/Ivanestver/lammps-file-parser/structs"
 )
 
 func TestEdgeEquals(t *testing.T) {
@@ -18,7 +28,7 @@ func TestEdgeEquals(t *testing.T) {
 		Number: 0,
 		Edge:   [2]int{1, 2},
 	}
-	if !edge1.Equals(&edge2) {
+	if equal, err := edge1.Equals(&edge2); !equal || err != nil {
 		t.Errorf("Equal edges are not equal. Edge1 - %v, Edge2 - %v", edge1, edge2)
 	}
 
@@ -26,7 +36,7 @@ func TestEdgeEquals(t *testing.T) {
 		Number: 0,
 		Edge:   [2]int{2, 1},
 	}
-	if !edge1.Equals(&edge3) {
+	if equal, err := edge1.Equals(&edge3); !equal || err != nil {
 		t.Errorf("Equal edges are not equal. Edge1 - %v, Edge2 - %v", edge1, edge3)
 	}
 
@@ -34,7 +44,7 @@ func TestEdgeEquals(t *testing.T) {
 		Number: 1,
 		Edge:   [2]int{1, 2},
 	}
-	if edge1.Equals(&edge4) {
+	if equal, err := edge1.Equals(&edge4); equal || err != nil {
 		t.Errorf("Equal edges are not equal. Edge1 - %v, Edge2 - %v", edge1, edge4)
 	}
 
@@ -42,12 +52,29 @@ func TestEdgeEquals(t *testing.T) {
 		Number: 0,
 		Edge:   [2]int{3, 1},
 	}
-	defer func() {
-		if r := recover(); r == nil {
-			t.Errorf("No panic when it must be. Edge1 - %v, Edge2 - %v", edge1, edge5)
-		}
-	}()
-	edge1.Equals(&edge5)
+	if _, err := edge1.Equals(&edge5); !errors.Is(err, types.ErrEdgeMismatch) {
+		t.Errorf("No error when it must be. Edge1 - %v, Edge2 - %v", edge1, edge5)
+	}
+}
+
+func TestGraphConnect(t *testing.T) {
+	graphJson := makeTestGraph()
+	expected := [][]int{
+		{1, 2, 3},
+		{0, 2},
+		{0, 1, 3},
+		{0, 2},
+	}
+	real := graphJson.Graph.GetOnlyNumbers()
+	if slices.CompareFunc(expected, real, slices.Compare) != 0 {
+		t.Errorf("Wrong adjacency lists. Expected: %v, got: %v", expected, real)
+	}
+	if !graphJson.Graph.IsConnected(2, 0) || graphJson.Graph.IsConnected(1, 3) {
+		t.Errorf("Wrong connectivity. Got: %v", real)
+	}
+	if edge := graphJson.Graph.GetEdge(3, 2); edge == nil || edge.Number != 2 {
+		t.Errorf("Expected the edge 2 between 3 and 2, got: %v", edge)
+	}
 }
 
 func TestDFS(t *testing.T) {
@@ -56,7 +83,7 @@ func TestDFS(t *testing.T) {
 	real := dfs.Traverse(0)
 	expected := getTestSpanningTree()
 	equal := slices.CompareFunc(real, expected, func(p1, p2 *types.Edge) int {
-		if p1.Equals(p2) {
+		if equal, err := p1.Equals(p2); equal && err == nil {
 			return 0
 		} else {
 			return -1
@@ -73,11 +100,51 @@ func TestDFS(t *testing.T) {
 	}
 }
 
+func TestDFSForest(t *testing.T) {
+	graphJson := makeDisconnectedTestGraph()
+	dfs := algs.MakeDFS(graphJson.Points, graphJson.Graph)
+	forest, components := dfs.TraverseForest()
+	expectedComponents := []int{0, 0, 0, 0, 1, 1, 1, 2, 3, 3}
+	if slices.Compare(expectedComponents, components) != 0 {
+		t.Errorf("Wrong components. Expected: %v, got: %v", expectedComponents, components)
+	}
+	expectedSizes := []int{3, 2, 0, 1}
+	realSizes := make([]int, len(forest))
+	for i, tree := range forest {
+		realSizes[i] = len(tree)
+	}
+	if slices.Compare(expectedSizes, realSizes) != 0 {
+		t.Errorf("Wrong spanning trees sizes. Expected: %v, got: %v", expectedSizes, realSizes)
+	}
+	if again, _ := dfs.TraverseForest(); !slices.EqualFunc(forest, again, slices.Equal) {
+		t.Errorf("Second traversal differs. Expected: %v, got: %v", forest, again)
+	}
+}
+
+func TestCalculateCyclesDisconnected(t *testing.T) {
+	data := makeData(makeDisconnectedTestGraph())
+	if len(data.supportVectors) != 3 {
+		t.Fatalf("Expected 3 support vectors, got: %d", len(data.supportVectors))
+	}
+	cyclesOfEdges, _ := calculateCyclesOfEdges(data, Options{})
+	cycles := make([]Cycle, len(cyclesOfEdges))
+	for i, cycleOfEdges := range cyclesOfEdges {
+		if len(cycleOfEdges) != 3 {
+			t.Errorf("Expected only triangles, got: %v", getSortedEdgeNumbers(cycleOfEdges))
+		}
+		cycles[i] = Cycle{Component: data.components[cycleOfEdges[0].Edge[0]]}
+	}
+	cyclesByComponent := GetCyclesByComponent(cycles)
+	if len(cyclesByComponent[0]) != 2 || len(cyclesByComponent[1]) != 1 || len(cyclesByComponent) != 2 {
+		t.Errorf("Wrong cycles per component: %v", cyclesByComponent)
+	}
+}
+
 func TestGetNonSpanningTreeEdges(t *testing.T) {
 	real := getRealNonSpanningTreeEdges()
 	expected := getTestNonSpanningTreeEdges()
 	equal := slices.CompareFunc(expected, real, func(e1, e2 *types.Edge) int {
-		if e1.Equals(e2) {
+		if equal, err := e1.Equals(e2); equal && err == nil {
 			return 0
 		} else {
 			return 1
@@ -104,7 +171,7 @@ func TestGetSupportVectors(t *testing.T) {
 	graphJson := makeTestGraph()
 	expected := getTestSupportVectors()
 	nonSpanningTreeEdges := getRealNonSpanningTreeEdges()
-	real := getSupportVectors(nonSpanningTreeEdges, len(graphJson.Points), graphJson.Edges)
+	real := getSupportVectors(nonSpanningTreeEdges, len(graphJson.Edges))
 	res := slices.CompareFunc(expected, real, func(e1, e2 types.SupportVector) int {
 		return slices.Compare(e1, e2)
 	})
@@ -113,14 +180,18 @@ func TestGetSupportVectors(t *testing.T) {
 	}
 }
 
-func TestCreateDoubledGraph(t *testing.T) {
-	graphJson := makeTestGraph()
+func TestDoubledGraph(t *testing.T) {
 	expected := getTestDoubledGraph()
-	testSupportVector := types.SupportVector{0, 0, 0, 0, 1}
-	real := createDoubledGraph(graphJson.Graph, graphJson.Edges, testSupportVector)
-	res := slices.CompareFunc(expected, real, func(l1, l2 []*types.Edge) int {
-		return slices.CompareFunc(l1, l2, func(e1, e2 *types.Edge) int {
-			if (e1 == nil && e2 == nil) || e1.Equals(e2) {
+	doubledGraph := makeTestDoubledGraph()
+	real := data_structs.NewGraph(doubledGraph.Len())
+	for point := range real {
+		for adjacency := range doubledGraph.Neighbours(point) {
+			real.Connect(point, adjacency.Point, adjacency.Edge)
+		}
+	}
+	res := slices.CompareFunc(expected, real, func(l1, l2 []data_structs.Adjacency) int {
+		return slices.CompareFunc(l1, l2, func(a1, a2 data_structs.Adjacency) int {
+			if equal, err := a1.Edge.Equals(a2.Edge); a1.Point == a2.Point && equal && err == nil {
 				return 0
 			} else {
 				return 1
@@ -136,33 +207,473 @@ func TestCreateDoubledGraph(t *testing.T) {
 
 func TestGetFirstCycle(t *testing.T) {
 	expected := getTestCycles()
-	doubledGraph := getTestDoubledGraph()
-	real := getCycle(doubledGraph, 0, len(doubledGraph)/2)
+	doubledGraph := makeTestDoubledGraph()
+	real, weight := newCycleSearch(doubledGraph.Len()).getCycle(doubledGraph, getTestWeights(), 0, math.Inf(1), 0)
+	if weight != 3 {
+		t.Errorf("Wrong cycle weight. Expected: 3, got: %v", weight)
+	}
 	for _, expectedCycle := range expected {
-		res := slices.CompareFunc(expectedCycle, real, func(e1, e2 *types.Edge) int {
-			if e1.Equals(e2) {
-				return 0
-			} else {
-				return 1
-			}
-		})
-		if res == 0 {
+		if slices.Compare(getSortedEdgeNumbers(expectedCycle), getSortedEdgeNumbers(real)) == 0 {
 			return
 		}
 	}
 	message := "Expected one of the following:"
 	for _, expectedCycle := range expected {
-		exp := make([]types.Edge, len(expectedCycle))
-		for i := range exp {
-			exp[i] = *expectedCycle[i]
+		message = fmt.Sprintf("%s %v, ", message, getSortedEdgeNumbers(expectedCycle))
+	}
+	t.Errorf("%s, got: %v", message, getSortedEdgeNumbers(real))
+}
+
+func TestGetFirstCycleBound(t *testing.T) {
+	doubledGraph := makeTestDoubledGraph()
+	if real, weight := newCycleSearch(doubledGraph.Len()).getCycle(doubledGraph, getTestWeights(), 0, 2, 0); real != nil {
+		t.Errorf("Expected no cycle lighter than 2, got: %v with weight %v", getSortedEdgeNumbers(real), weight)
+	}
+	if real, _ := newCycleSearch(doubledGraph.Len()).getCycle(doubledGraph, getTestWeights(), 0, 3, 0); len(real) != 3 {
+		t.Errorf("Expected a cycle of weight 3, got: %v", getSortedEdgeNumbers(real))
+	}
+}
+
+func TestGetDistanceTo(t *testing.T) {
+	first := types.NewPoint(0, 1, 2, 3)
+	second := types.NewPoint(1, 4, 6, 3)
+	if real := first.GetDistanceTo(second); real != 5 {
+		t.Errorf("Expected: 5, got: %v", real)
+	}
+}
+
+func TestEuclideanWeighting(t *testing.T) {
+	/*
+		Three paths of two bonds between 0 and 4, the one through 3 is the
+		shortest and the one through 1 is the longest.
+		    2
+		0 3 4
+		    1
+	*/
+	makeGraph := func() *GraphJson {
+		points := []*types.Point{
+			types.NewPoint(0, 0, 0, 0),
+			types.NewPoint(1, 1, -2, 0),
+			types.NewPoint(2, 1, 1, 0),
+			types.NewPoint(3, 1, 0.1, 0),
+			types.NewPoint(4, 2, 0, 0),
+		}
+		edges := []*types.Edge{
+			{Number: 0, Edge: [2]int{0, 1}},
+			{Number: 1, Edge: [2]int{1, 4}},
+			{Number: 2, Edge: [2]int{0, 2}},
+			{Number: 3, Edge: [2]int{2, 4}},
+			{Number: 4, Edge: [2]int{0, 3}},
+			{Number: 5, Edge: [2]int{3, 4}},
+		}
+		return NewGraphJson(points, edges, makeGraph(edges, len(points)))
+	}
+	shortBonds := BondTypeWeight{Weights: map[int]float64{1: 0.5}, Default: 1}
+	for _, weight := range []EdgeWeight{EuclideanWeight{}, TieBreakWeight{UnitWeight{}, EuclideanWeight{}}, shortBonds} {
+		graphJson := makeGraph()
+		graphJson.Edges[4].Type = 1
+		graphJson.Edges[5].Type = 1
+		cycles, err := calculateCyclesOfEdges(makeData(graphJson), Options{Weight: weight})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, cycle := range cycles {
+			if !slices.ContainsFunc(cycle, func(edge *types.Edge) bool { return edge.Number == 4 }) {
+				t.Errorf("Weight %T: expected every cycle to pass through 3, got: %v", weight, getSortedEdgeNumbers(cycle))
+			}
+		}
+	}
+}
+
+func TestBoxDisplacement(t *testing.T) {
+	box := types.NewBox(types.Vector{0, 0, 0}, types.Vector{10, 10, 10})
+	box.Periodic[2] = false
+	from := types.NewPoint(0, 0.5, 9.5, 0.5)
+	to := types.NewPoint(1, 9.5, 0.5, 9.5)
+	expected := types.Vector{-1, 1, 9}
+	if real := box.GetDisplacement(from, to); real != expected {
+		t.Errorf("Expected: %v, got: %v", expected, real)
+	}
+	expected = types.Vector{9, -9, 9}
+	var noBox *types.Box
+	if real := noBox.GetDisplacement(from, to); real != expected {
+		t.Errorf("Expected without a box: %v, got: %v", expected, real)
+	}
+}
+
+func TestPeriodicCycle(t *testing.T) {
+	/*
+		A unit square crossing the boundary x = 0 of the box [0, 10).
+	*/
+	box := types.NewBox(types.Vector{0, 0, 0}, types.Vector{10, 10, 10})
+	points := []*types.Point{
+		types.NewPoint(0, 9.5, 1, 1),
+		types.NewPoint(1, 0.5, 1, 1),
+		types.NewPoint(2, 0.5, 2, 1),
+		types.NewPoint(3, 9.5, 2, 1),
+	}
+	edges := []*types.Edge{
+		{Number: 0, Edge: [2]int{0, 1}},
+		{Number: 1, Edge: [2]int{1, 2}},
+		{Number: 2, Edge: [2]int{2, 3}},
+		{Number: 3, Edge: [2]int{3, 0}},
+	}
+	weights, err := getEdgeWeights(EuclideanWeight{}, points, edges, box)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []float64{1, 1, 1, 1}; slices.Compare(expected, weights) != 0 {
+		t.Errorf("Wrong bond lengths. Expected: %v, got: %v", expected, weights)
+	}
+	coordinates := getUnwrappedCoordinates(points, box)
+	expected := []types.Vector{{9.5, 1, 1}, {10.5, 1, 1}, {10.5, 2, 1}, {9.5, 2, 1}}
+	if !slices.Equal(expected, coordinates) {
+		t.Errorf("Wrong unwrapped coordinates. Expected: %v, got: %v", expected, coordinates)
+	}
+}
+
+func TestWindingCycles(t *testing.T) {
+	/*
+		A ring of 4 points along x winding around the box [0, 4) and a
+		triangle that does not.
+	*/
+	box := types.NewBox(types.Vector{0, 0, 0}, types.Vector{4, 4, 4})
+	points := []*types.Point{
+		types.NewPoint(0, 0.5, 1, 1),
+		types.NewPoint(1, 1.5, 1, 1),
+		types.NewPoint(2, 2.5, 1, 1),
+		types.NewPoint(3, 3.5, 1, 1),
+		types.NewPoint(4, 0.5, 3, 1),
+		types.NewPoint(5, 3.5, 3, 1),
+		types.NewPoint(6, 0, 3.5, 1),
+	}
+	edges := []*types.Edge{
+		{Number: 0, Edge: [2]int{0, 1}},
+		{Number: 1, Edge: [2]int{1, 2}},
+		{Number: 2, Edge: [2]int{2, 3}},
+		{Number: 3, Edge: [2]int{3, 0}},
+		{Number: 4, Edge: [2]int{4, 5}},
+		{Number: 5, Edge: [2]int{5, 6}},
+		{Number: 6, Edge: [2]int{6, 4}},
+	}
+	graphJson := NewGraphJson(points, edges, makeGraph(edges, len(points)))
+	for _, excludeWinding := range []bool{false, true} {
+		options := Options{Box: box, ExcludeWinding: excludeWinding}
+		data := makeData(graphJson)
+		cyclesOfEdges, err := calculateCyclesOfEdges(data, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result := makeResult(data, cyclesOfEdges, options)
+		contractible, winding := SplitWindingCycles(result.Cycles)
+		if len(contractible) != 1 || len(contractible[0].Points) != 3 {
+			t.Errorf("Expected the triangle to be contractible, got: %v", contractible)
+		}
+		if excludeWinding && len(winding) != 0 {
+			t.Errorf("Expected the winding cycles to be excluded, got: %v", winding)
+		}
+		if result.CyclomaticNumber != 2 {
+			t.Errorf("Expected the cyclomatic number 2, got: %d", result.CyclomaticNumber)
+		}
+		if !excludeWinding {
+			if len(winding) != 1 || (winding[0].Winding != [3]int{1, 0, 0} && winding[0].Winding != [3]int{-1, 0, 0}) {
+				t.Errorf("Expected the ring along x to wind once, got: %v", winding)
+			}
+		}
+	}
+}
+
+func TestNonPositiveWeight(t *testing.T) {
+	weight := BondTypeWeight{Weights: map[int]float64{1: 2}}
+	if _, err := calculateCyclesOfEdges(makeData(makeTestGraph()), Options{Weight: weight}); err == nil {
+		t.Error("Expected an error for bonds of unknown types weighing 0")
+	}
+}
+
+func TestBondOrderWeight(t *testing.T) {
+	weight := BondOrderWeight{Orders: map[int]float64{2: 2}}
+	if real := weight.GetWeight(&types.Edge{Type: 2}, nil, nil); real != 0.5 {
+		t.Errorf("Expected a double bond to weigh 0.5, got: %v", real)
+	}
+	if real := weight.GetWeight(&types.Edge{Type: 1}, nil, nil); real != 1 {
+		t.Errorf("Expected a single bond to weigh 1, got: %v", real)
+	}
+}
+
+func TestGetEdgeWeight(t *testing.T) {
+	if weight, err := GetEdgeWeight("euclidean"); err != nil || weight != (EuclideanWeight{}) {
+		t.Errorf("Expected the euclidean weight, got: %v, %v", weight, err)
+	}
+	if _, err := GetEdgeWeight("bogus"); err == nil {
+		t.Error("Expected an error for an unknown weight")
+	}
+}
+
+func TestLoadWeightTable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "weights.txt")
+	content := "# type weight\n1 1.5\n\n2 0.75 # aromatic\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	real, err := LoadWeightTable(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[int]float64{1: 1.5, 2: 0.75}
+	if !maps.Equal(expected, real) {
+		t.Errorf("Expected: %v, got: %v", expected, real)
+	}
+
+	if err := os.WriteFile(path, []byte("1 1.5 2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadWeightTable(path); err == nil {
+		t.Error("Expected an error for a malformed line")
+	}
+}
+
+func TestGetCycleSources(t *testing.T) {
+	graphJson := makeTestGraph()
+	expected := []int{0, 1}
+	real := getCycleSources(graphJson.Edges, makeSupportVector(5, 1, 4))
+	if slices.Compare(expected, real) != 0 {
+		t.Errorf("Expected: %v, got: %v", expected, real)
+	}
+}
+
+func TestCalculateCyclesOnGrid(t *testing.T) {
+	cycles, _ := calculateCyclesOfEdges(makeData(NewGraphJson(makeGraphBig())), Options{Workers: 1})
+	if len(cycles) != 4 {
+		t.Fatalf("Expected 4 cycles, got: %d", len(cycles))
+	}
+	for _, cycle := range cycles {
+		if len(cycle) != 4 {
+			t.Errorf("Expected only squares, got: %v", getSortedEdgeNumbers(cycle))
+		}
+	}
+}
+
+func TestCalculateCyclesParallel(t *testing.T) {
+	expected, _ := calculateCyclesOfEdges(makeData(NewGraphJson(makeGraphBig())), Options{Workers: 1})
+	for _, workers := range []int{2, 4, 16} {
+		for range 10 {
+			real, _ := calculateCyclesOfEdges(makeData(NewGraphJson(makeGraphBig())), Options{Workers: workers})
+			equal := slices.EqualFunc(expected, real, func(c1, c2 []*types.Edge) bool {
+				return slices.EqualFunc(c1, c2, func(e1, e2 *types.Edge) bool {
+					return e1.Number == e2.Number
+				})
+			})
+			if !equal {
+				t.Fatalf("Cycles depend on %d workers. Expected: %v, got: %v", workers, expected, real)
+			}
+		}
+	}
+}
+
+func TestCalculateCyclesOfGraph(t *testing.T) {
+	points, edges, _ := makeGraphBig()
+	result, err := CalculateCyclesOfGraph(MakeGraphJson(points, edges), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Cycles) != 4 || result.CyclomaticNumber != 4 || result.ComponentsCount != 1 {
+		t.Fatalf("Expected 4 cycles in 1 component, got: %d cycles, cyclomatic number %d, %d components",
+			len(result.Cycles), result.CyclomaticNumber, result.ComponentsCount)
+	}
+	if result.TotalWeight != 16 {
+		t.Errorf("Expected the total weight 16, got: %v", result.TotalWeight)
+	}
+	for i, cycle := range result.Cycles {
+		if len(cycle.Points) != 4 || len(cycle.Edges) != 4 || cycle.Weight != 4 {
+			t.Errorf("Expected only squares, got: %v", cycle.Points)
+		}
+		if cycle.Index != i {
+			t.Errorf("Expected the index %d, got: %d", i, cycle.Index)
+		}
+		for j, edge := range cycle.Edges {
+			if !cycle.Incidence.Test(edge.Number) {
+				t.Errorf("Expected the edge %d in the incidence vector of the cycle %d", edge.Number, i)
+			}
+			next := cycle.Points[(j+1)%len(cycle.Points)].PointID
+			if edge.GetOtherSide(cycle.Points[j].PointID) != next {
+				t.Errorf("Expected the edge %v to connect the points %d and %d", edge.Edge, cycle.Points[j].PointID, next)
+			}
+		}
+		if product, err := cycle.Incidence.GetScalarMultiplication(cycle.Incidence); err != nil || product != uint64(len(cycle.Edges)) {
+			t.Errorf("Expected the incidence vector of the cycle %d to have only its edges", i)
+		}
+		bondIDs := getEdgeNumbers(cycle.Edges)
+		atomIDs := make([]int, len(cycle.Points))
+		for j := range bondIDs {
+			bondIDs[j]++
+			atomIDs[j] = cycle.Points[j].PointID + 1
+		}
+		if !slices.Equal(cycle.GetBondIDs(), bondIDs) || !slices.Equal(cycle.GetAtomIDs(), atomIDs) {
+			t.Errorf("Expected the 1-based bond IDs %v and atom IDs %v, got: %v, %v",
+				bondIDs, atomIDs, cycle.GetBondIDs(), cycle.GetAtomIDs())
+		}
+	}
+}
+
+func TestCalculateCyclesOfJson(t *testing.T) {
+	points, edges, _ := makeGraphBig()
+	lammpsStruct := lammps_structs.NewLammpsStruct()
+	for _, point := range points {
+		lammpsStruct.Atoms = append(lammpsStruct.Atoms, *lammps_structs.NewAtom("C", point.PointID+1, 1, 1, 0, point.X, point.Y, point.Z))
+	}
+	for _, edge := range edges {
+		ends := [2]*lammps_structs.Atom{&lammpsStruct.Atoms[edge.Edge[0]], &lammpsStruct.Atoms[edge.Edge[1]]}
+		lammpsStruct.Bonds = append(lammpsStruct.Bonds, *lammps_structs.NewBond(edge.Number+1, 1, ends))
+	}
+	jsonObj, err := json.Marshal(lammpsStruct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := CalculateCyclesOfGraph(MakeGraphJson(points, edges), Options{})
+	real, err := CalculateCycles(string(jsonObj), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	equal := slices.EqualFunc(expected.Cycles, real.Cycles, func(c1, c2 Cycle) bool {
+		return slices.EqualFunc(c1.Points, c2.Points, func(p1, p2 *types.Point) bool {
+			return p1.PointID == p2.PointID
+		})
+	})
+	if !equal {
+		t.Errorf("Expected: %v, got: %v", expected, real)
+	}
+}
+
+func TestGraphErrors(t *testing.T) {
+	makeJson := func(atomIDs []int, bonds [][3]int) string {
+		lammpsStruct := lammps_structs.NewLammpsStruct()
+		for _, id := range atomIDs {
+			lammpsStruct.Atoms = append(lammpsStruct.Atoms, *lammps_structs.NewAtom("C", id, 1, 1, 0, 0, 0, 0))
+		}
+		for _, bond := range bonds {
+			// The atom ID 0 is a missing end
+			var ends [2]*lammps_structs.Atom
+			for i, id := range bond[1:] {
+				if id != 0 {
+					ends[i] = lammps_structs.NewAtom("C", id, 1, 1, 0, 0, 0, 0)
+				}
+			}
+			lammpsStruct.Bonds = append(lammpsStruct.Bonds, *lammps_structs.NewBond(bond[0], 1, ends))
 		}
-		message = fmt.Sprintf("%s %v, ", message, expectedCycle)
+		jsonObj, err := json.Marshal(lammpsStruct)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(jsonObj)
+	}
+	jsonTests := []struct {
+		name    string
+		atomIDs []int
+		bonds   [][3]int
+		kind    error
+		bond    int
+		atom    int
+	}{
+		{"duplicate bond", []int{1, 2, 3}, [][3]int{{1, 1, 2}, {1, 2, 3}}, ErrDuplicateBond, 1, NoID},
+		{"duplicate atom", []int{1, 2, 2}, [][3]int{{1, 1, 2}}, ErrDuplicateAtom, NoID, 2},
+		{"dangling atom", []int{1, 2, 3}, [][3]int{{1, 1, 2}, {2, 2, 4}}, ErrDanglingAtom, 2, 4},
+		{"missing end", []int{1, 2, 3}, [][3]int{{1, 1, 0}}, ErrDanglingAtom, 1, NoID},
+		{"self-loop", []int{1, 2, 3}, [][3]int{{1, 1, 2}, {2, 3, 3}}, ErrSelfLoop, 2, 3},
+		{"parallel bond", []int{1, 2, 3}, [][3]int{{1, 1, 2}, {2, 2, 3}, {3, 2, 1}}, ErrParallelBond, 3, NoID},
+		{"bond out of range", []int{1, 2, 3}, [][3]int{{1, 1, 2}, {3, 2, 3}}, ErrOutOfRange, 3, NoID},
+		{"atom out of range", []int{1, 2, 0}, [][3]int{{1, 1, 2}}, ErrOutOfRange, NoID, 0},
+	}
+	for _, test := range jsonTests {
+		_, err := CalculateCycles(makeJson(test.atomIDs, test.bonds), Options{})
+		var graphError *GraphError
+		if !errors.Is(err, test.kind) || !errors.As(err, &graphError) {
+			t.Errorf("%s: expected %v, got: %v", test.name, test.kind, err)
+			continue
+		}
+		if graphError.Bond != test.bond || graphError.Atom != test.atom {
+			t.Errorf("%s: expected the bond %d and the atom %d, got: %v", test.name, test.bond, test.atom, err)
+		}
+	}
+	if _, err := CalculateCycles(makeJson([]int{2, 3, 1}, [][3]int{{2, 1, 2}, {3, 2, 3}, {1, 3, 1}}), Options{}); err != nil {
+		t.Errorf("Expected the IDs in any order, got: %v", err)
+	}
+
+	graphTests := []struct {
+		name  string
+		edges []*types.Edge
+		kind  error
+	}{
+		{"duplicate bond", []*types.Edge{{Number: 0, Edge: [2]int{0, 1}}, {Number: 0, Edge: [2]int{1, 2}}}, ErrDuplicateBond},
+		{"dangling atom", []*types.Edge{{Number: 0, Edge: [2]int{0, 3}}}, ErrDanglingAtom},
+		{"self-loop", []*types.Edge{{Number: 0, Edge: [2]int{1, 1}}}, ErrSelfLoop},
+		{"parallel bond", []*types.Edge{{Number: 0, Edge: [2]int{0, 1}}, {Number: 1, Edge: [2]int{1, 0}}}, ErrParallelBond},
+		{"bond out of range", []*types.Edge{{Number: 1, Edge: [2]int{0, 1}}}, ErrOutOfRange},
+		{"removed bond", []*types.Edge{nil, {Number: 1, Edge: [2]int{0, 1}}}, nil},
 	}
-	r := make([]types.Edge, len(real))
-	for i := range real {
-		r[i] = *real[i]
+	for _, test := range graphTests {
+		points, _ := makeGraphOfBonds(3, nil)
+		graphJson := NewGraphJson(points, test.edges, nil)
+		if _, err := CalculateCyclesOfGraph(graphJson, Options{}); !errors.Is(err, test.kind) {
+			t.Errorf("%s: expected %v, got: %v", test.name, test.kind, err)
+		}
+		if _, err := Verify(graphJson, &Result{}, Options{}); !errors.Is(err, test.kind) {
+			t.Errorf("%s: expected %v from Verify, got: %v", test.name, test.kind, err)
+		}
+	}
+
+	points, _ := makeGraphOfBonds(3, nil)
+	pointTests := []struct {
+		name      string
+		graphJson *GraphJson
+		kind      error
+		atom      int
+	}{
+		{"dangling atom of the built graph", MakeGraphJson(points[:2], []*types.Edge{{Number: 0, Edge: [2]int{0, 5}}}), ErrDanglingAtom, 6},
+		{"nil point", NewGraphJson([]*types.Point{points[0], nil, points[2]}, nil, nil), ErrMissingAtom, 2},
+		{"graph size", NewGraphJson(points, nil, data_structs.NewGraph(2)), ErrGraphSize, NoID},
+	}
+	for _, test := range pointTests {
+		_, err := CalculateCyclesOfGraph(test.graphJson, Options{})
+		var graphError *GraphError
+		if !errors.Is(err, test.kind) || !errors.As(err, &graphError) || graphError.Atom != test.atom {
+			t.Errorf("%s: expected %v of the atom %d, got: %v", test.name, test.kind, test.atom, err)
+		}
+	}
+	if _, err := CalculateCyclesOfGraph(nil, Options{}); !errors.Is(err, ErrNilGraph) {
+		t.Errorf("Expected %v, got: %v", ErrNilGraph, err)
 	}
-	t.Errorf("%s, got: %v", message, r)
+}
+
+func TestStackAndPath(t *testing.T) {
+	stack := data_structs.Stack[int]{}
+	stack.Push(1)
+	if p, err := stack.Pop(); p != 1 || err != nil {
+		t.Errorf("Expected 1, got: %d, %v", p, err)
+	}
+	if _, err := stack.Pop(); !errors.Is(err, data_structs.ErrEmptyStack) {
+		t.Errorf("Expected the empty stack, got: %v", err)
+	}
+	if _, err := stack.Pick(); !errors.Is(err, data_structs.ErrEmptyStack) {
+		t.Errorf("Expected the empty stack, got: %v", err)
+	}
+	path := types.Path{}
+	if _, err := path.Last(); !errors.Is(err, types.ErrEmptyPath) {
+		t.Errorf("Expected the empty path, got: %v", err)
+	}
+}
+
+func getEdgeNumbers(cycle []*types.Edge) []int {
+	numbers := make([]int, len(cycle))
+	for i, edge := range cycle {
+		numbers[i] = edge.Number
+	}
+	return numbers
+}
+
+func getSortedEdgeNumbers(cycle []*types.Edge) []int {
+	numbers := getEdgeNumbers(cycle)
+	slices.Sort(numbers)
+	return numbers
 }
 
 func TestTestScalarMultiplication(t *testing.T) {
@@ -171,14 +682,43 @@ func TestTestScalarMultiplication(t *testing.T) {
 		{Number: 1, Edge: [2]int{1, 2}},
 		{Number: 4, Edge: [2]int{0, 2}},
 	}
-	supportVector := types.SupportVector{0, 0, 0, 0, 1}
+	supportVector := makeSupportVector(5, 4)
 	expected := true
-	real := testScalarMultiplication(turnCycleIntoSupportVector(cycle, len(supportVector)), supportVector)
-	if expected != real {
+	real, err := testScalarMultiplication(turnCycleIntoSupportVector(cycle, 5), supportVector)
+	if err != nil || expected != real {
 		t.Error("Expected: true, got: false")
 	}
 }
 
+func TestSupportVector(t *testing.T) {
+	size := 130
+	first := makeSupportVector(size, 0, 64, 129)
+	second := makeSupportVector(size, 1, 64, 129)
+	for edgeNumber := 0; edgeNumber < size; edgeNumber++ {
+		expected := edgeNumber == 0 || edgeNumber == 64 || edgeNumber == 129
+		if first.Test(edgeNumber) != expected {
+			t.Errorf("Wrong bit %d. Expected: %v, got: %v", edgeNumber, expected, !expected)
+		}
+	}
+	if real, err := first.GetScalarMultiplication(second); err != nil || real != 2 {
+		t.Errorf("Wrong scalar multiplication. Expected: 2, got: %d, %v", real, err)
+	}
+	if _, err := first.GetScalarMultiplication(types.NewSupportVector(64)); !errors.Is(err, types.ErrSizeMismatch) {
+		t.Errorf("Expected the size mismatch, got: %v", err)
+	}
+	if real := first.AND(second).GetParity(); real != 0 {
+		t.Errorf("Wrong parity. Expected: 0, got: %d", real)
+	}
+	expected := makeSupportVector(size, 0, 1)
+	if real := first.XOR(second); slices.Compare(expected, real) != 0 {
+		t.Errorf("Wrong XOR. Expected: %v, got: %v", expected, real)
+	}
+	first.XORInPlace(second)
+	if slices.Compare(expected, first) != 0 {
+		t.Errorf("Wrong in-place XOR. Expected: %v, got: %v", expected, first)
+	}
+}
+
 func makeTestGraph() *GraphJson {
 	/*
 		1 *-* 1-2
@@ -200,15 +740,33 @@ func makeTestGraph() *GraphJson {
 		{Number: 4, Edge: [2]int{0, 2}},
 	}
 
-	graph := data_structs.Graph{
-		[]*types.Edge{nil, edges[0], edges[4], edges[3]},
-		[]*types.Edge{edges[0], nil, edges[1], nil},
-		[]*types.Edge{edges[4], edges[1], nil, edges[2]},
-		[]*types.Edge{edges[3], nil, edges[2], nil},
-	}
+	graph := data_structs.NewGraph(len(points))
+	graph.Connect(0, 1, edges[0])
+	graph.Connect(0, 2, edges[4])
+	graph.Connect(0, 3, edges[3])
+	graph.Connect(1, 2, edges[1])
+	graph.Connect(2, 3, edges[2])
 	return NewGraphJson(points, edges, graph)
 }
 
+func makeDisconnectedTestGraph() *GraphJson {
+	/*
+		the test graph, a triangle 4-5-6, a free point 7 and a bond 8-9
+	*/
+	graphJson := makeTestGraph()
+	for i := 4; i < 10; i++ {
+		graphJson.Points = append(graphJson.Points, types.NewPoint(i, float64(i), 0, 0))
+	}
+	graphJson.Edges = append(graphJson.Edges,
+		&types.Edge{Number: 5, Edge: [2]int{4, 5}},
+		&types.Edge{Number: 6, Edge: [2]int{5, 6}},
+		&types.Edge{Number: 7, Edge: [2]int{6, 4}},
+		&types.Edge{Number: 8, Edge: [2]int{8, 9}},
+	)
+	graphJson.Graph = makeGraph(graphJson.Edges, len(graphJson.Points))
+	return graphJson
+}
+
 func getTestSpanningTree() types.Path {
 	return []*types.Edge{
 		{Number: 0, Edge: [2]int{0, 1}}, {Number: 1, Edge: [2]int{1, 2}}, {Number: 2, Edge: [2]int{2, 3}},
@@ -225,43 +783,45 @@ func getTestNonSpanningTreeEdges() []*types.Edge {
 
 func getTestSupportVectors() []types.SupportVector {
 	return []types.SupportVector{
-		{0, 0, 0, 1, 0},
-		{0, 0, 0, 0, 1},
+		makeSupportVector(5, 3),
+		makeSupportVector(5, 4),
+	}
+}
+
+func getTestWeights() []float64 {
+	return []float64{1, 1, 1, 1, 1}
+}
+
+func makeSupportVector(size int, edgeNumbers ...int) types.SupportVector {
+	supportVector := types.NewSupportVector(size)
+	for _, edgeNumber := range edgeNumbers {
+		supportVector.Set(edgeNumber)
 	}
+	return supportVector
+}
+
+func makeTestDoubledGraph() *data_structs.DoubledGraph {
+	return data_structs.NewDoubledGraph(makeTestGraph().Graph, makeSupportVector(5, 4))
 }
 
 func getTestDoubledGraph() data_structs.Graph {
 	graphJson := makeTestGraph()
 	originSize := len(graphJson.Graph)
-	doubledSize := originSize * 2
-	doubledGraph := make(data_structs.Graph, doubledSize)
-	for i := 0; i < len(doubledGraph); i++ {
-		doubledGraph[i] = make([]*types.Edge, doubledSize)
-	}
-	doubledGraph[0][1] = graphJson.Graph[0][1]
-	doubledGraph[1][0] = graphJson.Graph[1][0]
-	doubledGraph[originSize+0][originSize+1] = graphJson.Graph[0][1]
-	doubledGraph[originSize+1][originSize+0] = graphJson.Graph[1][0]
-
-	doubledGraph[1][2] = graphJson.Graph[1][2]
-	doubledGraph[2][1] = graphJson.Graph[2][1]
-	doubledGraph[originSize+1][originSize+2] = graphJson.Graph[1][2]
-	doubledGraph[originSize+2][originSize+1] = graphJson.Graph[2][1]
-
-	doubledGraph[2][3] = graphJson.Graph[2][3]
-	doubledGraph[3][2] = graphJson.Graph[3][2]
-	doubledGraph[originSize+2][originSize+3] = graphJson.Graph[2][3]
-	doubledGraph[originSize+3][originSize+2] = graphJson.Graph[3][2]
-
-	doubledGraph[3][0] = graphJson.Graph[3][0]
-	doubledGraph[0][3] = graphJson.Graph[0][3]
-	doubledGraph[originSize+3][originSize+0] = graphJson.Graph[3][0]
-	doubledGraph[originSize+0][originSize+3] = graphJson.Graph[0][3]
-
-	doubledGraph[0][originSize+2] = graphJson.Graph[0][2]
-	doubledGraph[originSize+2][0] = graphJson.Graph[2][0]
-	doubledGraph[originSize+0][2] = graphJson.Graph[0][2]
-	doubledGraph[2][originSize+0] = graphJson.Graph[2][0]
+	doubledGraph := data_structs.NewGraph(originSize * 2)
+	doubledGraph.Connect(0, 1, graphJson.Graph.GetEdge(0, 1))
+	doubledGraph.Connect(originSize+0, originSize+1, graphJson.Graph.GetEdge(0, 1))
+
+	doubledGraph.Connect(1, 2, graphJson.Graph.GetEdge(1, 2))
+	doubledGraph.Connect(originSize+1, originSize+2, graphJson.Graph.GetEdge(1, 2))
+
+	doubledGraph.Connect(2, 3, graphJson.Graph.GetEdge(2, 3))
+	doubledGraph.Connect(originSize+2, originSize+3, graphJson.Graph.GetEdge(2, 3))
+
+	doubledGraph.Connect(3, 0, graphJson.Graph.GetEdge(3, 0))
+	doubledGraph.Connect(originSize+3, originSize+0, graphJson.Graph.GetEdge(3, 0))
+
+	doubledGraph.Connect(0, originSize+2, graphJson.Graph.GetEdge(0, 2))
+	doubledGraph.Connect(originSize+0, 2, graphJson.Graph.GetEdge(0, 2))
 	return doubledGraph
 }
 
@@ -309,12 +869,12 @@ func makeGraphSmall() *GraphJson {
 		{Number: 4, Edge: [2]int{0, 2}},
 	}
 
-	graph := data_structs.Graph{
-		[]*types.Edge{nil, edges[0], edges[4], edges[3]},
-		[]*types.Edge{edges[0], nil, edges[1], nil},
-		[]*types.Edge{edges[4], edges[1], nil, edges[2]},
-		[]*types.Edge{edges[3], nil, edges[2], nil},
-	}
+	graph := data_structs.NewGraph(len(points))
+	graph.Connect(0, 1, edges[0])
+	graph.Connect(0, 2, edges[4])
+	graph.Connect(0, 3, edges[3])
+	graph.Connect(1, 2, edges[1])
+	graph.Connect(2, 3, edges[2])
 	return NewGraphJson(points, edges, graph)
 }
 
@@ -354,14 +914,1083 @@ func makeGraphBig() ([]*types.Point, []*types.Edge, data_structs.Graph) {
 		{Number: 11, Edge: [2]int{7, 8}},
 	}
 
-	graph := make(data_structs.Graph, len(points))
-	for i := 0; i < len(graph); i++ {
-		graph[i] = make([]*types.Edge, len(graph))
-	}
-	for i, edge := range edges {
-		graph[edge.Edge[0]][edge.Edge[1]] = edges[i]
-		graph[edge.Edge[1]][edge.Edge[0]] = edges[i]
+	graph := data_structs.NewGraph(len(points))
+	for _, edge := range edges {
+		graph.Connect(edge.Edge[0], edge.Edge[1], edge)
 	}
 
 	return points, edges, graph
 }
+
+func TestGetStats(t *testing.T) {
+	result := &Result{CyclomaticNumber: 4, TotalWeight: 16}
+	for _, size := range []int{6, 5, 6, 7} {
+		result.Cycles = append(result.Cycles, Cycle{Points: make([]*types.Point, size)})
+	}
+	stats := result.GetStats()
+	if stats.RingsCount != 4 || stats.CyclomaticNumber != 4 || stats.TotalWeight != 16 {
+		t.Errorf("Wrong stats: %+v", stats)
+	}
+	if !maps.Equal(stats.SizeCounts, map[int]int{5: 1, 6: 2, 7: 1}) {
+		t.Errorf("Wrong size counts: %v", stats.SizeCounts)
+	}
+	if stats.MeanSize != 6 || stats.MedianSize != 6 {
+		t.Errorf("Expected the mean and median sizes 6, got: %v, %v", stats.MeanSize, stats.MedianSize)
+	}
+	result.Cycles = result.Cycles[:3]
+	if stats := result.GetStats(); stats.MedianSize != 6 || math.Abs(stats.MeanSize-17.0/3) > 1e-9 {
+		t.Errorf("Wrong mean and median sizes: %v, %v", stats.MeanSize, stats.MedianSize)
+	}
+	if stats := (&Result{}).GetStats(); stats.RingsCount != 0 || stats.MeanSize != 0 {
+		t.Errorf("Expected empty stats, got: %+v", stats)
+	}
+}
+
+func TestBasisIncremental(t *testing.T) {
+	random := rand.New(rand.NewPCG(1, 2))
+	for range 20 {
+		points := make([]*types.Point, 12)
+		for i := range points {
+			points[i] = types.NewPoint(i, 0, 0, 0)
+		}
+		basis, err := NewBasis(MakeGraphJson(points, make([]*types.Edge, 0)), Options{Workers: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for range 60 {
+			from, to := random.IntN(len(points)), random.IntN(len(points))
+			if from == to {
+				continue
+			}
+			if _, err := basis.RemoveBond(from, to); err != nil {
+				if _, err := basis.AddBond(from, to, 1); err != nil {
+					t.Fatal(err)
+				}
+			}
+			checkBasis(t, basis)
+		}
+	}
+	basis, _ := NewBasis(MakeGraphJson(makeGraphSmall().Points, makeGraphSmall().Edges), Options{})
+	if _, err := basis.AddBond(0, 1, 1); err == nil {
+		t.Errorf("Expected an error for the repeated bond")
+	}
+	if _, err := basis.RemoveBond(1, 3); err == nil {
+		t.Errorf("Expected an error for the missing bond")
+	}
+	if _, err := basis.AddBond(0, 4, 1); err == nil {
+		t.Errorf("Expected an error for the missing point")
+	}
+}
+
+// checkBasis compares the incrementally updated basis with the one calculated
+// from scratch.
+func checkBasis(t *testing.T, basis *Basis) {
+	t.Helper()
+	edges := make([]*types.Edge, 0)
+	for _, edge := range basis.graphJson.Edges {
+		if edge != nil {
+			edges = append(edges, &types.Edge{Number: len(edges), Edge: edge.Edge})
+		}
+	}
+	expected, err := CalculateCyclesOfGraph(MakeGraphJson(basis.graphJson.Points, edges), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	real := basis.GetResult()
+	if real.CyclomaticNumber != expected.CyclomaticNumber || len(real.Cycles) != len(expected.Cycles) {
+		t.Fatalf("Expected %d cycles, got: %d cycles with the cyclomatic number %d",
+			len(expected.Cycles), len(real.Cycles), real.CyclomaticNumber)
+	}
+	if real.TotalWeight != expected.TotalWeight {
+		t.Fatalf("Expected the total weight %v, got: %v", expected.TotalWeight, real.TotalWeight)
+	}
+	incidences := make([]types.SupportVector, len(real.Cycles))
+	for i, cycle := range real.Cycles {
+		incidences[i] = cycle.Incidence
+	}
+	if rank := getRank(incidences); rank != len(real.Cycles) {
+		t.Fatalf("Expected the cycles to be independent, got the rank %d of %d", rank, len(real.Cycles))
+	}
+}
+
+// getRank returns the GF(2) rank of the vectors
+func getRank(vectors []types.SupportVector) int {
+	rows := make([]types.SupportVector, len(vectors))
+	for i := range vectors {
+		rows[i] = slices.Clone(vectors[i])
+	}
+	rank := 0
+	for bit := 0; rank < len(rows) && bit < len(rows[0])*64; bit++ {
+		pivot := slices.IndexFunc(rows[rank:], func(row types.SupportVector) bool { return row.Test(bit) })
+		if pivot == -1 {
+			continue
+		}
+		rows[rank], rows[rank+pivot] = rows[rank+pivot], rows[rank]
+		for i := range rows {
+			if i != rank && rows[i].Test(bit) {
+				rows[i].XORInPlace(rows[rank])
+			}
+		}
+		rank++
+	}
+	return rank
+}
+
+func TestRelevantCycles(t *testing.T) {
+	tests := []struct {
+		name      string
+		points    int
+		bonds     [][2]int
+		basis     int
+		relevant  int
+		ringSizes []int
+	}{
+		// Every triangle of K4 is relevant, but only three of them are in a basis
+		{"K4", 4, [][2]int{{0, 1}, {0, 2}, {0, 3}, {1, 2}, {1, 3}, {2, 3}}, 3, 4, []int{3, 3, 3, 3}},
+		{"cube", 8, [][2]int{
+			{0, 1}, {1, 2}, {2, 3}, {3, 0}, {4, 5}, {5, 6}, {6, 7}, {7, 4}, {0, 4}, {1, 5}, {2, 6}, {3, 7},
+		}, 5, 6, []int{4, 4, 4, 4, 4, 4}},
+		// Three paths of three bonds between the bridgeheads 0 and 1
+		{"bicyclo[2.2.2]octane", 8, [][2]int{
+			{0, 2}, {2, 3}, {3, 1}, {0, 4}, {4, 5}, {5, 1}, {0, 6}, {6, 7}, {7, 1},
+		}, 2, 3, []int{6, 6, 6}},
+		// The envelope of naphthalene is the sum of the two lighter rings
+		{"naphthalene", 10, [][2]int{
+			{0, 1}, {1, 2}, {2, 3}, {3, 4}, {4, 5}, {5, 0}, {4, 6}, {6, 7}, {7, 8}, {8, 9}, {9, 5},
+		}, 2, 2, []int{6, 6}},
+	}
+	for _, test := range tests {
+		points, edges := makeGraphOfBonds(test.points, test.bonds)
+		result, err := CalculateCyclesOfGraph(MakeGraphJson(points, edges), Options{Mode: ModeRelevant})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.CyclomaticNumber != test.basis || len(result.Cycles) != test.relevant {
+			t.Errorf("%s: expected %d relevant cycles of the basis of %d, got: %d of %d",
+				test.name, test.relevant, test.basis, len(result.Cycles), result.CyclomaticNumber)
+		}
+		sizes := make([]int, len(result.Cycles))
+		for i, cycle := range result.Cycles {
+			sizes[i] = len(cycle.Points)
+		}
+		if !slices.Equal(sizes, test.ringSizes) {
+			t.Errorf("%s: expected the ring sizes %v, got: %v", test.name, test.ringSizes, sizes)
+		}
+
+		// The relevant cycles do not depend on the numbering of the points
+		permutation := rand.New(rand.NewPCG(3, 4)).Perm(test.points)
+		permuted := make([][2]int, len(test.bonds))
+		for i, bond := range test.bonds {
+			permuted[i] = [2]int{permutation[bond[0]], permutation[bond[1]]}
+		}
+		points, edges = makeGraphOfBonds(test.points, permuted)
+		permutedResult, _ := CalculateCyclesOfGraph(MakeGraphJson(points, edges), Options{Mode: ModeRelevant})
+		if len(permutedResult.Cycles) != test.relevant {
+			t.Errorf("%s: expected %d relevant cycles after renumbering, got: %d", test.name, test.relevant, len(permutedResult.Cycles))
+		}
+	}
+	if _, err := CalculateCyclesOfGraph(makeGraphSmall(), Options{Mode: "all"}); err == nil {
+		t.Errorf("Expected an error for the unknown mode")
+	}
+}
+
+func makeGraphOfBonds(pointsCount int, bonds [][2]int) ([]*types.Point, []*types.Edge) {
+	points := make([]*types.Point, pointsCount)
+	for i := range points {
+		points[i] = types.NewPoint(i, 0, 0, 0)
+	}
+	edges := make([]*types.Edge, len(bonds))
+	for i, bond := range bonds {
+		edges[i] = &types.Edge{Number: i, Edge: bond}
+	}
+	return points, edges
+}
+
+func TestRingModes(t *testing.T) {
+	// The square 0-1-2-3 and the path 2-4-5-6-0 make two 6-rings that differ
+	// by the square and share the path, so they are one unique ring family
+	points, edges := makeGraphOfBonds(7, [][2]int{{0, 1}, {1, 2}, {2, 3}, {3, 0}, {2, 4}, {4, 5}, {5, 6}, {6, 0}})
+	tests := []struct {
+		mode        Mode
+		sizes       []int
+		families    []int
+		familySizes []int
+	}{
+		{ModeSSSR, []int{4, 6}, []int{0, 0}, []int{0, 0}},
+		{ModeESSR, []int{4, 6, 6}, []int{0, 1, 1}, []int{1, 2, 2}},
+		{ModeURF, []int{4, 6}, []int{0, 1}, []int{1, 2}},
+	}
+	for _, test := range tests {
+		// The ring modes count the bonds, the zero bond lengths do not matter
+		result, err := CalculateCyclesOfGraph(MakeGraphJson(points, edges), Options{Mode: test.mode, Weight: EuclideanWeight{}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sizes := make([]int, len(result.Cycles))
+		families := make([]int, len(result.Cycles))
+		familySizes := make([]int, len(result.Cycles))
+		for i, cycle := range result.Cycles {
+			sizes[i] = len(cycle.Points)
+			families[i] = cycle.Family
+			familySizes[i] = cycle.FamilySize
+		}
+		slices.Sort(sizes)
+		if !slices.Equal(sizes, test.sizes) || !slices.Equal(families, test.families) || !slices.Equal(familySizes, test.familySizes) {
+			t.Errorf("%s: expected the sizes %v, families %v of sizes %v, got: %v, %v, %v",
+				test.mode, test.sizes, test.families, test.familySizes, sizes, families, familySizes)
+		}
+		if result.CyclomaticNumber != 2 || result.TotalWeight != 10 {
+			t.Errorf("%s: expected the basis of 2 cycles weighing 10, got: %d cycles weighing %v",
+				test.mode, result.CyclomaticNumber, result.TotalWeight)
+		}
+	}
+}
+
+func TestMaxRingSize(t *testing.T) {
+	// The same square and 6-rings, the path is a straight line across the
+	// square so that the 6-rings are lighter than the square
+	points, edges := makeGraphOfBonds(7, [][2]int{{0, 1}, {1, 2}, {2, 3}, {3, 0}, {2, 4}, {4, 5}, {5, 6}, {6, 0}})
+	for i, position := range [][2]float64{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {7.5, 7.5}, {5, 5}, {2.5, 2.5}} {
+		points[i].X, points[i].Y = position[0], position[1]
+	}
+	tests := []struct {
+		name    string
+		options Options
+		sizes   []int
+		cut     int
+		weight  float64
+	}{
+		{"basis", Options{MaxRingSize: 5}, []int{4}, 1, 4},
+		{"large enough", Options{MaxRingSize: 6}, []int{4, 6}, 0, 10},
+		{"relevant", Options{Mode: ModeRelevant, MaxRingSize: 5}, []int{4}, 1, 4},
+		{"relevant large enough", Options{Mode: ModeRelevant, MaxRingSize: 6}, []int{4, 6, 6}, 0, 10},
+		{"euclidean", Options{Weight: EuclideanWeight{}, MaxRingSize: 5}, []int{}, 2, 0},
+		{"relevant euclidean", Options{Mode: ModeRelevant, Weight: EuclideanWeight{}, MaxRingSize: 5}, []int{}, 2, 0},
+		{"euclidean large enough", Options{Weight: EuclideanWeight{}, MaxRingSize: 6}, []int{6, 6}, 0, 40 + 20*math.Sqrt2},
+	}
+	for _, test := range tests {
+		result, err := CalculateCyclesOfGraph(MakeGraphJson(points, edges), test.options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sizes := make([]int, len(result.Cycles))
+		for i, cycle := range result.Cycles {
+			sizes[i] = len(cycle.Points)
+		}
+		slices.Sort(sizes)
+		if !slices.Equal(sizes, test.sizes) || len(result.Cut) != test.cut || math.Abs(result.TotalWeight-test.weight) > 1e-9 {
+			t.Errorf("%s: expected the sizes %v, %d cut cycles and the weight %v, got: %v, %v, %v",
+				test.name, test.sizes, test.cut, test.weight, sizes, result.Cut, result.TotalWeight)
+		}
+		for _, cycle := range result.Cycles {
+			if slices.Contains(result.Cut, cycle.Index) {
+				t.Errorf("%s: the cycle %d is cut but kept", test.name, cycle.Index)
+			}
+		}
+	}
+
+	// The cycles that fit are the ones of a minimum cycle basis, whatever the
+	// weights
+	random := rand.New(rand.NewPCG(20, 3))
+	for iteration := range 300 {
+		graph := makeErdosRenyiGraph(random, 3+random.IntN(8), 0.2+0.4*random.Float64())
+		if graph.getCyclomaticNumber() > 10 {
+			continue
+		}
+		graphJson := graph.makeGraphJson(random)
+		options := Options{MaxRingSize: 3 + random.IntN(5)}
+		if iteration%2 == 1 {
+			options.Weight = EuclideanWeight{}
+		}
+		weights, err := getEdgeWeights(options.Weight, graphJson.Points, graphJson.Edges, options.Box)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := getBruteForceWeight(graphJson, weights, options.MaxRingSize)
+		for _, algorithm := range []Algorithm{AlgorithmDePina, AlgorithmHorton} {
+			options.Algorithm = algorithm
+			result, err := CalculateCyclesOfGraph(graphJson, options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !data_structs.IsSameWeight(result.TotalWeight, expected) {
+				t.Fatalf("%s, options %+v: expected the total weight %v, got %v",
+					graph.name, options, expected, result.TotalWeight)
+			}
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	random := rand.New(rand.NewPCG(21, 1))
+	for iteration := range 200 {
+		graph := makeErdosRenyiGraph(random, 4+random.IntN(14), 0.1+0.35*random.Float64())
+		options := Options{}
+		if iteration%2 == 1 {
+			options.Weight = EuclideanWeight{}
+		}
+		graphJson := graph.makeGraphJson(random)
+		result, err := CalculateCyclesOfGraph(graphJson, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		verification, err := Verify(graphJson, result, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := verification.Err(); err != nil {
+			t.Fatalf("%s, bonds %v: %v", graph.name, graph.bonds, err)
+		}
+	}
+
+	// The basis of the square 0-1-2-3 and a 6-ring around it, the other 6-ring
+	// is the sum of both
+	points, edges := makeGraphOfBonds(7, [][2]int{{0, 1}, {1, 2}, {2, 3}, {3, 0}, {2, 4}, {4, 5}, {5, 6}, {6, 0}})
+	graphJson := MakeGraphJson(points, edges)
+	relevant, err := CalculateCyclesOfGraph(graphJson, Options{Mode: ModeRelevant})
+	if err != nil {
+		t.Fatal(err)
+	}
+	square, ring, otherRing := relevant.Cycles[0], relevant.Cycles[1], relevant.Cycles[2]
+	broken := ring
+	broken.Points = slices.Clone(ring.Points)
+	slices.Reverse(broken.Points)
+	tests := []struct {
+		name   string
+		cycles []Cycle
+		weight float64
+		failed func(verification *Verification) error
+	}{
+		{"minimum", []Cycle{square, ring}, 10, func(v *Verification) error { return v.Err() }},
+		{"not simple", []Cycle{square, broken}, 10, func(v *Verification) error { return v.Simple }},
+		{"dependent", []Cycle{square, ring, otherRing}, 16, func(v *Verification) error { return v.Independent }},
+		{"too few", []Cycle{square}, 4, func(v *Verification) error { return v.Count }},
+		{"not minimum", []Cycle{ring, otherRing}, 12, func(v *Verification) error { return v.Minimal }},
+		{"wrong total weight", []Cycle{square, ring}, 12, func(v *Verification) error { return v.Minimal }},
+	}
+	for _, test := range tests {
+		result := &Result{Cycles: test.cycles, CyclomaticNumber: 2, ComponentsCount: 1, TotalWeight: test.weight}
+		verification, err := Verify(graphJson, result, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		failed := test.failed(verification)
+		if (test.name == "minimum") != (failed == nil) {
+			t.Errorf("%s: unexpected results of the checks: %v", test.name, verification.Err())
+		}
+		if failed != nil && !errors.Is(verification.Err(), failed) {
+			t.Errorf("%s: the joined errors miss %v", test.name, failed)
+		}
+	}
+}
+
+func TestHorton(t *testing.T) {
+	random := rand.New(rand.NewPCG(22, 7))
+	for iteration := range 300 {
+		graph := makeErdosRenyiGraph(random, 3+random.IntN(15), 0.1+0.5*random.Float64())
+		options := Options{Mode: []Mode{ModeBasis, ModeRelevant}[iteration%2], MaxRingSize: iteration % 7}
+		if iteration%3 == 0 {
+			options.Weight = EuclideanWeight{}
+		}
+		graphJson := graph.makeGraphJson(random)
+		depina, err := CalculateCyclesOfGraph(graphJson, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		options.Algorithm = AlgorithmHorton
+		horton, err := CalculateCyclesOfGraph(graphJson, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		weights := func(result *Result) []float64 {
+			weights := make([]float64, len(result.Cycles))
+			for i, cycle := range result.Cycles {
+				weights[i] = math.Round(cycle.Weight*1e9) / 1e9
+			}
+			slices.Sort(weights)
+			return weights
+		}
+		if !slices.Equal(weights(depina), weights(horton)) || len(depina.Cut) != len(horton.Cut) ||
+			math.Abs(depina.TotalWeight-horton.TotalWeight) > 1e-9 {
+			t.Fatalf("bonds %v, options %+v: expected the weights %v of the cut %v, got %v of the cut %v",
+				graph.bonds, options, weights(depina), depina.Cut, weights(horton), horton.Cut)
+		}
+		if options.Mode == ModeBasis {
+			incidences := make([]types.SupportVector, len(horton.Cycles))
+			for i, cycle := range horton.Cycles {
+				incidences[i] = cycle.Incidence
+			}
+			if len(incidences) > 0 && getRank(incidences) != len(incidences) {
+				t.Fatalf("bonds %v: the cycles of Horton's algorithm are dependent", graph.bonds)
+			}
+		}
+	}
+
+	if _, err := CalculateCyclesOfGraph(MakeGraphSmall(), Options{Algorithm: "unknown"}); err == nil {
+		t.Errorf("expected an error for an unknown algorithm")
+	}
+}
+
+// testGraph is a graph of the generators of the property tests
+type testGraph struct {
+	name   string
+	points int
+	bonds  [][2]int
+}
+
+// makeErdosRenyiGraph connects every pair of the points with the probability
+func makeErdosRenyiGraph(random *rand.Rand, pointsCount int, probability float64) testGraph {
+	graph := testGraph{name: fmt.Sprintf("G(%d, %.2f)", pointsCount, probability), points: pointsCount}
+	for i := range pointsCount {
+		for j := i + 1; j < pointsCount; j++ {
+			if random.Float64() < probability {
+				graph.bonds = append(graph.bonds, [2]int{i, j})
+			}
+		}
+	}
+	return graph
+}
+
+func makeGridGraph(rows, columns int) testGraph {
+	graph := testGraph{name: fmt.Sprintf("grid %dx%d", rows, columns), points: rows * columns}
+	for i := range rows {
+		for j := range columns {
+			if j+1 < columns {
+				graph.bonds = append(graph.bonds, [2]int{i*columns + j, i*columns + j + 1})
+			}
+			if i+1 < rows {
+				graph.bonds = append(graph.bonds, [2]int{i*columns + j, (i+1)*columns + j})
+			}
+		}
+	}
+	return graph
+}
+
+// makeLadderGraph connects two paths by the rungs, the circular ladder is a
+// prism
+func makeLadderGraph(rungs int, circular bool) testGraph {
+	graph := testGraph{name: fmt.Sprintf("ladder %d", rungs), points: 2 * rungs}
+	if circular {
+		graph.name = fmt.Sprintf("prism %d", rungs)
+	}
+	for i := range rungs {
+		graph.bonds = append(graph.bonds, [2]int{2 * i, 2*i + 1})
+		if i+1 < rungs || (circular && rungs > 2) {
+			next := (i + 1) % rungs
+			graph.bonds = append(graph.bonds, [2]int{2 * i, 2 * next}, [2]int{2*i + 1, 2*next + 1})
+		}
+	}
+	return graph
+}
+
+// makeFullereneGraph makes the C20 dodecahedron, the dual of the icosahedron,
+// or the C60 truncated icosahedron. Both are made of the pentagons and the
+// hexagons of the faces, the minimum cycle basis is all the faces but one
+// hexagon or one pentagon of C20.
+func makeFullereneGraph(truncated bool) testGraph {
+	phi := (1 + math.Sqrt(5)) / 2
+	vertices := make([][3]float64, 0, 12)
+	for _, a := range []float64{-1, 1} {
+		for _, b := range []float64{-phi, phi} {
+			vertices = append(vertices, [3]float64{0, a, b}, [3]float64{a, b, 0}, [3]float64{b, 0, a})
+		}
+	}
+	adjacent := func(u, v int) bool {
+		distance := 0.0
+		for k := range 3 {
+			distance += (vertices[u][k] - vertices[v][k]) * (vertices[u][k] - vertices[v][k])
+		}
+		return math.Abs(distance-4) < 1e-9
+	}
+	if !truncated {
+		// The points are the triangles of the icosahedron, the ones sharing an
+		// edge are bonded
+		graph := testGraph{name: "C20"}
+		triangles := make([][3]int, 0, 20)
+		for u := range 12 {
+			for v := u + 1; v < 12; v++ {
+				for w := v + 1; w < 12; w++ {
+					if adjacent(u, v) && adjacent(v, w) && adjacent(u, w) {
+						triangles = append(triangles, [3]int{u, v, w})
+					}
+				}
+			}
+		}
+		graph.points = len(triangles)
+		for i := range triangles {
+			for j := i + 1; j < len(triangles); j++ {
+				shared := 0
+				for _, u := range triangles[i] {
+					if slices.Contains(triangles[j][:], u) {
+						shared++
+					}
+				}
+				if shared == 2 {
+					graph.bonds = append(graph.bonds, [2]int{i, j})
+				}
+			}
+		}
+		return graph
+	}
+	// The points are the ends of the edges of the icosahedron cut at the
+	// thirds: the middle thirds are bonds and the ends around a vertex make a
+	// pentagon
+	graph := testGraph{name: "C60"}
+	ends := make(map[[2]int]int)
+	for u := range 12 {
+		for v := range 12 {
+			if u != v && adjacent(u, v) {
+				ends[[2]int{u, v}] = len(ends)
+			}
+		}
+	}
+	graph.points = len(ends)
+	for end, point := range ends {
+		u, v := end[0], end[1]
+		if u < v {
+			graph.bonds = append(graph.bonds, [2]int{point, ends[[2]int{v, u}]})
+		}
+		for w := v + 1; w < 12; w++ {
+			if w != u && adjacent(u, w) && adjacent(v, w) {
+				graph.bonds = append(graph.bonds, [2]int{point, ends[[2]int{u, w}]})
+			}
+		}
+	}
+	slices.SortFunc(graph.bonds, func(b1, b2 [2]int) int { return slices.Compare(b1[:], b2[:]) })
+	return graph
+}
+
+// makeUnionGraph puts the graphs side by side
+func makeUnionGraph(graphs ...testGraph) testGraph {
+	union := testGraph{name: "union"}
+	for _, graph := range graphs {
+		union.name += " " + graph.name
+		for _, bond := range graph.bonds {
+			union.bonds = append(union.bonds, [2]int{bond[0] + union.points, bond[1] + union.points})
+		}
+		union.points += graph.points
+	}
+	return union
+}
+
+// makeGraphJson places the points randomly for the Euclidean weights
+func (graph testGraph) makeGraphJson(random *rand.Rand) *GraphJson {
+	points, edges := makeGraphOfBonds(graph.points, graph.bonds)
+	for _, point := range points {
+		point.X, point.Y, point.Z = random.Float64(), random.Float64(), random.Float64()
+	}
+	return MakeGraphJson(points, edges)
+}
+
+// getCyclomaticNumber counts the components by union-find
+func (graph testGraph) getCyclomaticNumber() int {
+	parents := make([]int, graph.points)
+	for i := range parents {
+		parents[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parents[i] != i {
+			parents[i] = find(parents[i])
+		}
+		return parents[i]
+	}
+	components := graph.points
+	for _, bond := range graph.bonds {
+		if i, j := find(bond[0]), find(bond[1]); i != j {
+			parents[i] = j
+			components--
+		}
+	}
+	return len(graph.bonds) - graph.points + components
+}
+
+func makeTestGraphs(random *rand.Rand) []testGraph {
+	points, edges, _ := makeGraphBig()
+	big := testGraph{name: "big", points: len(points)}
+	for _, edge := range edges {
+		big.bonds = append(big.bonds, edge.Edge)
+	}
+	graphs := []testGraph{
+		big,
+		makeGridGraph(1, 5),
+		makeGridGraph(4, 6),
+		makeLadderGraph(6, false),
+		makeLadderGraph(7, true),
+		makeFullereneGraph(false),
+		makeFullereneGraph(true),
+		makeUnionGraph(makeGridGraph(3, 3), makeLadderGraph(4, true), makeErdosRenyiGraph(random, 8, 0.4)),
+		makeUnionGraph(makeFullereneGraph(false), testGraph{name: "point", points: 1}),
+	}
+	for range 40 {
+		pointsCount := 3 + random.IntN(10)
+		graphs = append(graphs, makeErdosRenyiGraph(random, pointsCount, 0.15+0.45*random.Float64()))
+	}
+	return graphs
+}
+
+// getBruteForceWeight takes the independent simple cycles of the graph in the
+// order of their weights, the graph must have few cycles. Only the weights of
+// the taken cycles of at most maxSize edges are summed when it is positive.
+func getBruteForceWeight(graphJson *GraphJson, weights []float64, maxSize int) float64 {
+	seen := make(map[string]bool)
+	cycles := make([][]*types.Edge, 0)
+	var walk func(start, point int, visited []bool, path []*types.Edge)
+	walk = func(start, point int, visited []bool, path []*types.Edge) {
+		for _, adjacency := range graphJson.Graph[point] {
+			if adjacency.Point == start && len(path) >= 2 {
+				cycle := append(slices.Clone(path), adjacency.Edge)
+				if key := turnCycleIntoSupportVector(cycle, len(graphJson.Edges)).Key(); !seen[key] {
+					seen[key] = true
+					cycles = append(cycles, cycle)
+				}
+				continue
+			}
+			if adjacency.Point < start || visited[adjacency.Point] {
+				continue
+			}
+			visited[adjacency.Point] = true
+			walk(start, adjacency.Point, visited, append(path, adjacency.Edge))
+			visited[adjacency.Point] = false
+		}
+	}
+	for start := range graphJson.Graph {
+		visited := make([]bool, len(graphJson.Graph))
+		visited[start] = true
+		walk(start, start, visited, nil)
+	}
+	slices.SortStableFunc(cycles, func(c1, c2 []*types.Edge) int {
+		return cmp.Compare(getCycleWeight(weights, c1), getCycleWeight(weights, c2))
+	})
+	basis := data_structs.NewGF2Basis()
+	weight := 0.0
+	for _, cycle := range cycles {
+		if basis.Add(turnCycleIntoSupportVector(cycle, len(graphJson.Edges))) && (maxSize <= 0 || len(cycle) <= maxSize) {
+			weight += getCycleWeight(weights, cycle)
+		}
+	}
+	return weight
+}
+
+// checkBasisInvariants checks that the result is a basis of simple cycles of
+// the size of the cyclomatic number and that it is as heavy as the brute
+// force basis, or as the one of Horton's algorithm when there are too many
+// cycles.
+func checkBasisInvariants(t *testing.T, graph testGraph, graphJson *GraphJson, result *Result, options Options) {
+	t.Helper()
+	cyclomaticNumber := graph.getCyclomaticNumber()
+	if len(result.Cycles) != cyclomaticNumber || result.CyclomaticNumber != cyclomaticNumber {
+		t.Fatalf("%s: expected %d cycles, got %d of the cyclomatic number %d",
+			graph.name, cyclomaticNumber, len(result.Cycles), result.CyclomaticNumber)
+	}
+	incidences := make([]types.SupportVector, len(result.Cycles))
+	for i, cycle := range result.Cycles {
+		if err := checkCycle(graphJson.Graph, &cycle); err != nil {
+			t.Fatalf("%s: the cycle %d is not simple: %v", graph.name, i, err)
+		}
+		incidences[i] = cycle.Incidence
+	}
+	if len(incidences) > 0 && getRank(incidences) != len(incidences) {
+		t.Fatalf("%s: the cycles are dependent", graph.name)
+	}
+	weights, err := getEdgeWeights(options.Weight, graphJson.Points, graphJson.Edges, options.Box)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected float64
+	if cyclomaticNumber <= 10 {
+		expected = getBruteForceWeight(graphJson, weights, 0)
+	} else {
+		data := makeData(graphJson)
+		data.weights = weights
+		for _, cycle := range getHortonCycles(data, math.Inf(1), 0) {
+			expected += getCycleWeight(weights, cycle)
+		}
+	}
+	if !data_structs.IsSameWeight(result.TotalWeight, expected) {
+		t.Fatalf("%s: expected the total weight %v, got %v", graph.name, expected, result.TotalWeight)
+	}
+}
+
+func TestBasisInvariants(t *testing.T) {
+	random := rand.New(rand.NewPCG(23, 5))
+	for _, graph := range makeTestGraphs(random) {
+		for _, options := range []Options{{}, {Weight: EuclideanWeight{}, Workers: 3}} {
+			graphJson := graph.makeGraphJson(random)
+			result, err := CalculateCyclesOfGraph(graphJson, options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkBasisInvariants(t, graph, graphJson, result, options)
+		}
+	}
+
+	// The faces of the fullerenes
+	for _, test := range []struct {
+		truncated bool
+		sizes     map[int]int
+	}{{false, map[int]int{5: 11}}, {true, map[int]int{5: 12, 6: 19}}} {
+		result, err := CalculateCyclesOfGraph(makeFullereneGraph(test.truncated).makeGraphJson(random), Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sizes := result.GetStats().SizeCounts; !maps.Equal(sizes, test.sizes) {
+			t.Errorf("Expected the ring sizes %v, got: %v", test.sizes, sizes)
+		}
+	}
+}
+
+// encodeTestGraph writes the graph in the format of FuzzCalculateCycles
+func encodeTestGraph(graph testGraph, euclidean bool) []byte {
+	data := []byte{byte(graph.points), 0}
+	if euclidean {
+		data[1] = 1
+	}
+	for _, bond := range graph.bonds {
+		data = append(data, byte(bond[0]), byte(bond[1]))
+	}
+	return data
+}
+
+// decodeTestGraph reads the number of points, the weight and the pairs of the
+// bonded points, the self-loops are skipped. It tells whether a pair of
+// points is bonded twice too.
+func decodeTestGraph(data []byte) (graph testGraph, euclidean bool, parallel bool) {
+	if len(data) < 2 {
+		return testGraph{name: "empty"}, false, false
+	}
+	graph = testGraph{name: fmt.Sprintf("fuzz %v", data), points: 1 + int(data[0])%64}
+	seen := make(map[[2]int]bool)
+	for i := 2; i+1 < len(data); i += 2 {
+		bond := [2]int{int(data[i]) % graph.points, int(data[i+1]) % graph.points}
+		if bond[0] == bond[1] {
+			continue
+		}
+		key := [2]int{min(bond[0], bond[1]), max(bond[0], bond[1])}
+		parallel = parallel || seen[key]
+		seen[key] = true
+		graph.bonds = append(graph.bonds, bond)
+	}
+	return graph, data[1]%2 == 1, parallel
+}
+
+func FuzzCalculateCycles(f *testing.F) {
+	random := rand.New(rand.NewPCG(23, 6))
+	for _, graph := range makeTestGraphs(random) {
+		if graph.points < 64 {
+			f.Add(encodeTestGraph(graph, false))
+			f.Add(encodeTestGraph(graph, true))
+		}
+	}
+	f.Add(encodeTestGraph(testGraph{points: 3, bonds: [][2]int{{0, 1}, {1, 2}, {2, 1}}}, false))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		graph, euclidean, parallel := decodeTestGraph(data)
+		options := Options{Workers: 2}
+		if euclidean {
+			options.Weight = EuclideanWeight{}
+		}
+		graphJson := graph.makeGraphJson(rand.New(rand.NewPCG(uint64(len(data)), 0)))
+		result, err := CalculateCyclesOfGraph(graphJson, options)
+		if parallel {
+			var graphError *GraphError
+			if !errors.Is(err, ErrParallelBond) || !errors.As(err, &graphError) {
+				t.Fatalf("%s: expected %v, got: %v", graph.name, ErrParallelBond, err)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkBasisInvariants(t, graph, graphJson, result, options)
+	})
+}
+
+// network is a generated molecular network of the benchmarks
+type network struct {
+	graphJson *GraphJson
+	box       *types.Box
+}
+
+func makeNetwork(positions []types.Vector, bonds [][2]int, box *types.Box) network {
+	points := make([]*types.Point, len(positions))
+	for i, position := range positions {
+		points[i] = types.NewPoint(i, position[0], position[1], position[2])
+	}
+	edges := make([]*types.Edge, len(bonds))
+	for i, bond := range bonds {
+		edges[i] = &types.Edge{Number: i, Edge: bond}
+	}
+	return network{MakeGraphJson(points, edges), box}
+}
+
+// getDiamondLattice returns the points of the periodic diamond lattice of
+// cells×cells×cells unit cells in the quarters of the cell and its bonds
+func getDiamondLattice(cells int) ([][3]int, [][2]int) {
+	size := 4 * cells
+	sites := make([][3]int, 0, 8*cells*cells*cells)
+	indices := make(map[[3]int]int)
+	for x := range size {
+		for y := range size {
+			for z := range size {
+				// The face centred sites and the ones shifted by a quarter of
+				// the diagonal
+				if x%2 == y%2 && y%2 == z%2 && (x+y+z)%4 == 3*(x%2) {
+					indices[[3]int{x, y, z}] = len(sites)
+					sites = append(sites, [3]int{x, y, z})
+				}
+			}
+		}
+	}
+	bonds := make([][2]int, 0, 2*len(sites))
+	for i, site := range sites {
+		if site[0]%2 == 1 {
+			continue
+		}
+		for _, shift := range [][3]int{{1, 1, 1}, {1, -1, -1}, {-1, 1, -1}, {-1, -1, 1}} {
+			var neighbour [3]int
+			for k := range 3 {
+				neighbour[k] = (site[k] + shift[k] + size) % size
+			}
+			bonds = append(bonds, [2]int{i, indices[neighbour]})
+		}
+	}
+	return sites, bonds
+}
+
+// makeDiamondNetwork makes about atomsCount atoms of periodic diamond
+func makeDiamondNetwork(atomsCount int) network {
+	cells := max(1, int(math.Round(math.Cbrt(float64(atomsCount)/8))))
+	sites, bonds := getDiamondLattice(cells)
+	positions := make([]types.Vector, len(sites))
+	for i, site := range sites {
+		positions[i] = types.Vector{float64(site[0]) / 4, float64(site[1]) / 4, float64(site[2]) / 4}
+	}
+	side := float64(cells)
+	return makeNetwork(positions, bonds, types.NewBox(types.Vector{0, 0, 0}, types.Vector{side, side, side}))
+}
+
+// makeGrapheneNetwork makes about atomsCount atoms of a graphene sheet
+// periodic in its plane. The zigzag rows of the atoms are bonded by every
+// second atom.
+func makeGrapheneNetwork(atomsCount int) network {
+	side := max(2, 2*int(math.Round(math.Sqrt(float64(atomsCount))/2)))
+	positions := make([]types.Vector, 0, side*side)
+	bonds := make([][2]int, 0, 3*side*side/2)
+	for i := range side {
+		for j := range side {
+			y := 1.5*float64(i) - 0.25
+			if (i+j)%2 == 0 {
+				y += 0.5
+				bonds = append(bonds, [2]int{i*side + j, (i+1)%side*side + j})
+			}
+			positions = append(positions, types.Vector{float64(j) * math.Sqrt(3) / 2, y, 0})
+			bonds = append(bonds, [2]int{i*side + j, i*side + (j+1)%side})
+		}
+	}
+	box := types.NewBox(types.Vector{0, -0.5, -1}, types.Vector{float64(side) * math.Sqrt(3) / 2, 1.5*float64(side) - 0.5, 1})
+	box.Periodic[2] = false
+	return makeNetwork(positions, bonds, box)
+}
+
+// makeAmorphousNetwork scatters atomsCount atoms at the unit density and
+// bonds the close ones, no atom has more than four bonds
+func makeAmorphousNetwork(atomsCount int) network {
+	random := rand.New(rand.NewPCG(24, uint64(atomsCount)))
+	const cutoff = 1.1
+	cellsCount := max(1, int(math.Cbrt(float64(atomsCount))/cutoff))
+	side := float64(cellsCount) * cutoff
+	box := types.NewBox(types.Vector{0, 0, 0}, types.Vector{side, side, side})
+	positions := make([]types.Vector, atomsCount)
+	cells := make(map[[3]int][]int)
+	for i := range positions {
+		var cell [3]int
+		for k := range 3 {
+			positions[i][k] = side * random.Float64()
+			cell[k] = min(cellsCount-1, int(positions[i][k]/cutoff))
+		}
+		cells[cell] = append(cells[cell], i)
+	}
+	network := makeNetwork(positions, nil, box)
+	points := network.graphJson.Points
+	degrees := make([]int, atomsCount)
+	bonds := make([][2]int, 0, 2*atomsCount)
+	for i, position := range positions {
+		neighbours := make([]int, 0)
+		for shift := range 27 {
+			var cell [3]int
+			for k, d := range [3]int{shift % 3, shift / 3 % 3, shift / 9} {
+				cell[k] = (min(cellsCount-1, int(position[k]/cutoff)) + d - 1 + cellsCount) % cellsCount
+			}
+			for _, j := range cells[cell] {
+				if j > i && !slices.Contains(neighbours, j) && box.GetDistance(points[i], points[j]) < cutoff {
+					neighbours = append(neighbours, j)
+				}
+			}
+		}
+		for _, j := range neighbours {
+			if degrees[i] < 4 && degrees[j] < 4 {
+				degrees[i]++
+				degrees[j]++
+				bonds = append(bonds, [2]int{i, j})
+			}
+		}
+	}
+	return makeNetwork(positions, bonds, box)
+}
+
+// makeGelNetwork makes about atomsCount atoms of an end-linked polymer gel:
+// the tetrafunctional crosslinks sit on a diamond lattice and are joined by
+// the strands of eight monomers, a tenth of the strands are broken into the
+// dangling ends
+func makeGelNetwork(atomsCount int) network {
+	const strandLength = 8
+	random := rand.New(rand.NewPCG(24, uint64(atomsCount)))
+	cells := max(1, int(math.Round(math.Cbrt(float64(atomsCount)/(8+16*strandLength)))))
+	sites, links := getDiamondLattice(cells)
+	side := float64(cells) * strandLength
+	box := types.NewBox(types.Vector{0, 0, 0}, types.Vector{side, side, side})
+	positions := make([]types.Vector, len(sites), len(sites)*(1+2*strandLength))
+	for i, site := range sites {
+		for k := range 3 {
+			positions[i][k] = float64(site[k]) * strandLength / 4
+		}
+	}
+	bonds := make([][2]int, 0, len(positions))
+	for _, link := range links {
+		from, to := positions[link[0]], positions[link[1]]
+		displacement := box.GetDisplacement(types.NewPoint(0, from[0], from[1], from[2]), types.NewPoint(0, to[0], to[1], to[2]))
+		broken := random.IntN(10) == 0
+		previous := link[0]
+		for k := 1; k <= strandLength; k++ {
+			position := from
+			for i := range 3 {
+				position[i] += displacement[i] * float64(k) / (strandLength + 1)
+			}
+			positions = append(positions, position)
+			if !broken || k != strandLength/2+1 {
+				bonds = append(bonds, [2]int{previous, len(positions) - 1})
+			}
+			previous = len(positions) - 1
+		}
+		bonds = append(bonds, [2]int{previous, link[1]})
+	}
+	return makeNetwork(positions, bonds, box)
+}
+
+func TestBenchmarkNetworks(t *testing.T) {
+	tests := []struct {
+		name       string
+		network    network
+		maxDegree  int
+		bondLength float64
+	}{
+		{"diamond", makeDiamondNetwork(1000), 4, math.Sqrt(3) / 4},
+		{"graphene", makeGrapheneNetwork(1000), 3, 1},
+		{"amorphous", makeAmorphousNetwork(1000), 4, 0},
+		{"gel", makeGelNetwork(1000), 4, 2 * math.Sqrt(3) / 9},
+	}
+	for _, test := range tests {
+		if count := len(test.network.graphJson.Points); count < 500 || count > 2000 {
+			t.Errorf("%s: expected about 1000 atoms, got %d", test.name, count)
+		}
+		for point, adjacencies := range test.network.graphJson.Graph {
+			if len(adjacencies) > test.maxDegree {
+				t.Fatalf("%s: the atom %d has %d bonds", test.name, point, len(adjacencies))
+			}
+		}
+		for _, edge := range test.network.graphJson.Edges {
+			points := test.network.graphJson.Points
+			length := test.network.box.GetDistance(points[edge.Edge[0]], points[edge.Edge[1]])
+			if test.bondLength > 0 && !data_structs.IsSameWeight(length, test.bondLength) || length > 1.1 {
+				t.Fatalf("%s: the bond %d is %v long", test.name, edge.Number, length)
+			}
+		}
+	}
+}
+
+var benchmarkNetworks = []struct {
+	name string
+	make func(atomsCount int) network
+}{
+	{"diamond", makeDiamondNetwork},
+	{"graphene", makeGrapheneNetwork},
+	{"amorphous", makeAmorphousNetwork},
+	{"gel", makeGelNetwork},
+}
+
+var benchmarkSizes = []struct {
+	name       string
+	atomsCount int
+}{{"1k", 1_000}, {"10k", 10_000}, {"100k", 100_000}}
+
+// largeBenchmarksVariable runs the benchmarks over the sizes that take
+// minutes to hours when it is set to 1:
+//
+//	CYCLES_LARGE_BENCHMARKS=1 go test -run '^$' -bench CalculateCycles -timeout 0 ./cycles_alg
+const largeBenchmarksVariable = "CYCLES_LARGE_BENCHMARKS"
+
+// runNetworkBenchmarks runs the benchmark over every network of every size,
+// the sizes larger than maxAtomsCount are skipped unless the large benchmarks
+// are asked for.
+func runNetworkBenchmarks(b *testing.B, maxAtomsCount int, benchmark func(b *testing.B, network network)) {
+	for _, size := range benchmarkSizes {
+		for _, generator := range benchmarkNetworks {
+			b.Run(generator.name+"/"+size.name, func(b *testing.B) {
+				if size.atomsCount > maxAtomsCount && os.Getenv(largeBenchmarksVariable) != "1" {
+					b.Skipf("the size takes too long, set %s=1 to run it", largeBenchmarksVariable)
+				}
+				network := generator.make(size.atomsCount)
+				b.ReportAllocs()
+				benchmark(b, network)
+			})
+		}
+	}
+}
+
+// BenchmarkCalculateCycles runs only the networks of 1k atoms by default, de
+// Pina's algorithm takes minutes on the ones of 10k atoms and hours on the
+// ones of 100k atoms
+func BenchmarkCalculateCycles(b *testing.B) {
+	runNetworkBenchmarks(b, 1_000, func(b *testing.B, network network) {
+		for b.Loop() {
+			if _, err := CalculateCyclesOfGraph(network.graphJson, Options{Box: network.box}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkDoubledGraph walks all the adjacencies of the doubled graph of the
+// first support vector
+func BenchmarkDoubledGraph(b *testing.B) {
+	runNetworkBenchmarks(b, 100_000, func(b *testing.B, network network) {
+		data := makeData(network.graphJson)
+		for b.Loop() {
+			doubledGraph := data_structs.NewDoubledGraph(data.graph, data.supportVectors[0])
+			for point := range doubledGraph.Len() {
+				for range doubledGraph.Neighbours(point) {
+				}
+			}
+		}
+	})
+}
+
+// BenchmarkGetCycle finds the shortest cycle through the first non-tree edge
+// from one of its ends
+func BenchmarkGetCycle(b *testing.B) {
+	runNetworkBenchmarks(b, 100_000, func(b *testing.B, network network) {
+		data := makeData(network.graphJson)
+		weights, err := getEdgeWeights(UnitWeight{}, data.points, data.edges, network.box)
+		if err != nil {
+			b.Fatal(err)
+		}
+		doubledGraph := data_structs.NewDoubledGraph(data.graph, data.supportVectors[0])
+		source := getCycleSources(data.edges, data.supportVectors[0])[0]
+		search := newCycleSearch(doubledGraph.Len())
+		for b.Loop() {
+			if cycle, _ := search.getCycle(doubledGraph, weights, source, math.Inf(1), 0); cycle == nil {
+				b.Fatal("no cycle found")
+			}
+		}
+	})
+}
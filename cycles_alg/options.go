@@ -0,0 +1,125 @@
+package cycles_alg
+
+import (
+	"cycles/data_structs"
+	"cycles/types"
+	"fmt"
+	"math"
+	"runtime"
+	"slices"
+)
+
+// Mode is the set of cycles to look for
+type Mode string
+
+const (
+	// ModeBasis finds a minimum cycle basis
+	ModeBasis Mode = "basis"
+	// ModeRelevant finds all the relevant cycles, the union of all the
+	// minimum cycle bases
+	ModeRelevant Mode = "relevant"
+	// ModeSSSR finds the smallest set of smallest rings, a minimum cycle basis
+	// by the bond count
+	ModeSSSR Mode = "sssr"
+	// ModeESSR finds the union of all the smallest sets of smallest rings,
+	// the relevant cycles by the bond count
+	ModeESSR Mode = "essr"
+	// ModeURF finds a cycle of every unique ring family of Kolodzik et al. by
+	// the bond count, the family sizes are the numbers of the relevant cycles
+	// in the families
+	ModeURF Mode = "urf"
+)
+
+var modes = []Mode{ModeBasis, ModeRelevant, ModeSSSR, ModeESSR, ModeURF}
+
+// Algorithm is the algorithm finding the minimum cycle basis
+type Algorithm string
+
+const (
+	// AlgorithmDePina finds the cycles one by one as the shortest cycles
+	// that are not orthogonal to the support vectors
+	AlgorithmDePina Algorithm = "depina"
+	// AlgorithmHorton takes the independent cycles out of the candidates
+	// made of the shortest paths in the order of their weights. It can be
+	// faster for small dense graphs.
+	AlgorithmHorton Algorithm = "horton"
+)
+
+var algorithms = []Algorithm{AlgorithmDePina, AlgorithmHorton}
+
+type Options struct {
+	// Workers is the number of goroutines looking for the cycle of a support
+	// vector, all the available CPUs are used when it is not positive.
+	Workers int
+	// Weight defines the weights of the bonds the basis is minimal by,
+	// UnitWeight is used when it is nil
+	Weight EdgeWeight
+	// Box makes the geometry periodic, the system is not periodic when it is
+	// nil
+	Box *types.Box
+	// ExcludeWinding leaves out the cycles winding around the periodic box
+	ExcludeWinding bool
+	// Mode is ModeBasis when it is empty. The ring modes ModeSSSR, ModeESSR
+	// and ModeURF count the bonds whatever Weight is.
+	Mode Mode
+	// MaxRingSize is the largest number of atoms of a cycle, the basis cycles
+	// that are larger are cut. The search drops the paths that can not close
+	// a ring within it when all the bonds weigh the same. The cycles are not
+	// limited when it is not positive.
+	MaxRingSize int
+	// Algorithm is AlgorithmDePina when it is empty
+	Algorithm Algorithm
+}
+
+func (options *Options) getWorkers() int {
+	if options.Workers > 0 {
+		return options.Workers
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+func (options *Options) fits(cycle []*types.Edge) bool {
+	return options.MaxRingSize <= 0 || len(cycle) <= options.MaxRingSize
+}
+
+// getMaxSize returns the number of edges the search for the cycles stops at.
+// The lightest cycles that fit are the ones of a minimum cycle basis only when
+// all the edges weigh the same, otherwise the search is not limited and the
+// basis is cut afterwards.
+func (options *Options) getMaxSize(edges []*types.Edge, weights []float64) int {
+	if options.MaxRingSize <= 0 {
+		return 0
+	}
+	weight := math.NaN()
+	for _, edge := range edges {
+		if edge == nil {
+			continue
+		}
+		if !math.IsNaN(weight) && !data_structs.IsSameWeight(weights[edge.Number], weight) {
+			return 0
+		}
+		weight = weights[edge.Number]
+	}
+	return options.MaxRingSize
+}
+
+// withMode checks the mode and the algorithm and returns the options with
+// them and the weight the mode implies set.
+func (options Options) withMode() (Options, error) {
+	if len(options.Mode) == 0 {
+		options.Mode = ModeBasis
+	}
+	if !slices.Contains(modes, options.Mode) {
+		return options, fmt.Errorf("unknown mode %q", options.Mode)
+	}
+	if len(options.Algorithm) == 0 {
+		options.Algorithm = AlgorithmDePina
+	}
+	if !slices.Contains(algorithms, options.Algorithm) {
+		return options, fmt.Errorf("unknown algorithm %q", options.Algorithm)
+	}
+	if options.Mode == ModeSSSR || options.Mode == ModeESSR || options.Mode == ModeURF {
+		options.Weight = UnitWeight{}
+	}
+	return options, nil
+}
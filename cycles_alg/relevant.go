@@ -0,0 +1,154 @@
+package cycles_alg
+
+import (
+	"cmp"
+	"cycles/data_structs"
+	"cycles/types"
+	"slices"
+)
+
+// family is a set of cycles of the shortest paths from its root. Either all
+// the cycles of a family are relevant or none is, since any two of them differ
+// by a sum of lighter cycles.
+type family struct {
+	data_structs.PathFamily
+	representative []*types.Edge
+	cycles         [][]*types.Edge
+	// level numbers the weights of the families and coset is the
+	// representative reduced by the lighter cycles, the cycles of the same
+	// level and coset differ by a sum of lighter cycles
+	level int
+	coset string
+}
+
+// getRelevantFamilies returns the families of the relevant cycles, the cycles
+// that are in some minimum cycle basis, ordered by their weights. A cycle is
+// relevant when it is not a sum of lighter cycles, and the lighter cycles of
+// the minimum cycle basis span all the lighter cycles. So the basis is the
+// reference, and no relevant cycle is heavier than its heaviest cycle.
+func getRelevantFamilies(data *Data, basis [][]*types.Edge) []*family {
+	basis = slices.Clone(basis)
+	slices.SortStableFunc(basis, func(c1, c2 []*types.Edge) int {
+		return cmp.Compare(getCycleWeight(data.weights, c1), getCycleWeight(data.weights, c2))
+	})
+	maxWeight := 0.0
+	if len(basis) > 0 {
+		maxWeight = getCycleWeight(data.weights, basis[len(basis)-1])
+	}
+
+	// 1. Find a cycle of every family not heavier than the basis allows
+	paths := data_structs.NewShortestPaths(data.graph, data.weights)
+	families := make([]*family, 0)
+	for root := range data.graph {
+		paths.Calculate(root, maxWeight/2)
+		for _, f := range paths.GetFamilies(maxWeight) {
+			for cycle := range paths.GetCycles(f) {
+				families = append(families, &family{PathFamily: f, representative: cycle})
+				break
+			}
+		}
+	}
+	slices.SortStableFunc(families, func(f1, f2 *family) int {
+		return cmp.Compare(f1.Weight, f2.Weight)
+	})
+
+	// 2. Keep the families that are not in the span of the lighter cycles
+	relevant := make([]*family, 0)
+	lighter := data_structs.NewGF2Basis()
+	next := 0
+	level := 0
+	for i, f := range families {
+		if i > 0 && !data_structs.IsSameWeight(f.Weight, families[i-1].Weight) {
+			level++
+		}
+		for ; next < len(basis); next++ {
+			weight := getCycleWeight(data.weights, basis[next])
+			if weight > f.Weight || data_structs.IsSameWeight(weight, f.Weight) {
+				break
+			}
+			lighter.Add(turnCycleIntoSupportVector(basis[next], len(data.edges)))
+		}
+		coset := lighter.Reduce(turnCycleIntoSupportVector(f.representative, len(data.edges)))
+		if coset.GetFirst() != -1 {
+			f.level = level
+			f.coset = coset.Key()
+			relevant = append(relevant, f)
+		}
+	}
+
+	// 3. Collect all the cycles of the relevant families
+	byRoot := make(map[int][]int)
+	for i, f := range relevant {
+		byRoot[f.Root] = append(byRoot[f.Root], i)
+	}
+	for root := range data.graph {
+		if len(byRoot[root]) == 0 {
+			continue
+		}
+		paths.Calculate(root, maxWeight/2)
+		for _, i := range byRoot[root] {
+			relevant[i].cycles = slices.Collect(paths.GetCycles(relevant[i].PathFamily))
+		}
+	}
+	return relevant
+}
+
+// getUniqueRingFamilies numbers the unique ring families of Kolodzik et al.
+// of the relevant families in the order of their weights. Two relevant cycles
+// of the same weight are related when they differ by a sum of lighter cycles
+// and share an edge, the unique ring families are the classes of the
+// transitive closure of the relation. The cycles of a family are always
+// related, so only the families are compared.
+func getUniqueRingFamilies(families []*family, edgesCount int) []int {
+	edgesOfFamilies := make([]types.SupportVector, len(families))
+	for i, f := range families {
+		edgesOfFamilies[i] = types.NewSupportVector(edgesCount)
+		for _, cycle := range f.cycles {
+			edgesOfFamilies[i].ORInPlace(turnCycleIntoSupportVector(cycle, edgesCount))
+		}
+	}
+	parents := make([]int, len(families))
+	for i := range parents {
+		parents[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parents[i] != i {
+			parents[i] = find(parents[i])
+		}
+		return parents[i]
+	}
+	type key struct {
+		level int
+		coset string
+	}
+	groups := make(map[key][]int)
+	for i, f := range families {
+		k := key{f.level, f.coset}
+		for _, j := range groups[k] {
+			// The vectors are of the same size, so there is no error
+			if shared, _ := edgesOfFamilies[i].GetScalarMultiplication(edgesOfFamilies[j]); shared > 0 {
+				parents[find(i)] = find(j)
+			}
+		}
+		groups[k] = append(groups[k], i)
+	}
+	numbers := make(map[int]int)
+	urfs := make([]int, len(families))
+	for i := range families {
+		root := find(i)
+		if _, ok := numbers[root]; !ok {
+			numbers[root] = len(numbers)
+		}
+		urfs[i] = numbers[root]
+	}
+	return urfs
+}
+
+func getCycleWeight(weights []float64, cycle []*types.Edge) float64 {
+	weight := 0.0
+	for _, edge := range cycle {
+		weight += weights[edge.Number]
+	}
+	return weight
+}
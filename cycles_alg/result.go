@@ -0,0 +1,89 @@
+package cycles_alg
+
+import "cycles/types"
+
+type Result struct {
+	// Cycles are the cycles of the mode ordered by their indices
+	Cycles []Cycle
+	// CyclomaticNumber is the size of the basis, |E| - |V| + c
+	CyclomaticNumber int
+	ComponentsCount  int
+	// TotalWeight is the weight of the basis cycles that are not cut
+	// including the ones left out of Cycles, or of all the cycles found for a
+	// result of NewResult
+	TotalWeight float64
+	// Cut are the indices of the basis cycles larger than MaxRingSize, they
+	// are left out of Cycles
+	Cut []int
+}
+
+type Cycle struct {
+	// Index is the position of the cycle among the cycles found
+	Index int
+	// Points go along the ring and the edge i connects the points i and i+1,
+	// the last edge closes the ring
+	Points []*types.Point
+	Edges  []*types.Edge
+	Weight float64
+	// Incidence is the GF(2) vector of the edges of the cycle
+	Incidence types.SupportVector
+	// Coordinates are the positions of the points unwrapped along the ring,
+	// so that the ring is whole even when it crosses a periodic boundary
+	Coordinates []types.Vector
+	Component   int
+	// Family is the number of the unique ring family of the cycle and
+	// FamilySize is the number of the relevant cycles in it. They are set only
+	// in the modes looking for the relevant cycles.
+	Family     int
+	FamilySize int
+	// Winding counts how many times the cycle winds around the periodic box
+	// along every dimension. Such a cycle is not a real ring and can not be
+	// contracted to a point.
+	Winding [3]int
+}
+
+// GetAtomIDs returns the LAMMPS IDs of the points along the ring, they start
+// from 1 while the PointID of a point starts from 0.
+func (cycle *Cycle) GetAtomIDs() []int {
+	atomIDs := make([]int, len(cycle.Points))
+	for i, point := range cycle.Points {
+		atomIDs[i] = point.PointID + 1
+	}
+	return atomIDs
+}
+
+// GetBondIDs returns the LAMMPS IDs of the edges along the ring, they start
+// from 1 while the Number of an edge starts from 0.
+func (cycle *Cycle) GetBondIDs() []int {
+	bondIDs := make([]int, len(cycle.Edges))
+	for i, edge := range cycle.Edges {
+		bondIDs[i] = edge.Number + 1
+	}
+	return bondIDs
+}
+
+func (cycle *Cycle) IsWinding() bool {
+	return cycle.Winding != [3]int{}
+}
+
+// SplitWindingCycles separates the contractible cycles from the winding ones.
+func SplitWindingCycles(cycles []Cycle) ([]Cycle, []Cycle) {
+	contractible := make([]Cycle, 0, len(cycles))
+	winding := make([]Cycle, 0)
+	for _, cycle := range cycles {
+		if cycle.IsWinding() {
+			winding = append(winding, cycle)
+		} else {
+			contractible = append(contractible, cycle)
+		}
+	}
+	return contractible, winding
+}
+
+func GetCyclesByComponent(cycles []Cycle) map[int][]Cycle {
+	cyclesByComponent := make(map[int][]Cycle)
+	for _, cycle := range cycles {
+		cyclesByComponent[cycle.Component] = append(cyclesByComponent[cycle.Component], cycle)
+	}
+	return cyclesByComponent
+}
@@ -0,0 +1,48 @@
+package cycles_alg
+
+import (
+	"slices"
+)
+
+type Stats struct {
+	RingsCount int
+	// SizeCounts maps a ring size to the number of rings of this size
+	SizeCounts       map[int]int
+	MeanSize         float64
+	MedianSize       float64
+	TotalWeight      float64
+	CyclomaticNumber int
+	// CutCount is the number of the basis cycles larger than MaxRingSize
+	CutCount int
+}
+
+// GetStats describes the ring sizes of the cycles of the result. The total
+// weight and the cyclomatic number are the ones of the whole basis.
+func (result *Result) GetStats() Stats {
+	stats := Stats{
+		RingsCount:       len(result.Cycles),
+		SizeCounts:       make(map[int]int),
+		TotalWeight:      result.TotalWeight,
+		CyclomaticNumber: result.CyclomaticNumber,
+		CutCount:         len(result.Cut),
+	}
+	if len(result.Cycles) == 0 {
+		return stats
+	}
+	sizes := make([]int, len(result.Cycles))
+	sum := 0
+	for i, cycle := range result.Cycles {
+		sizes[i] = len(cycle.Points)
+		sum += sizes[i]
+		stats.SizeCounts[sizes[i]]++
+	}
+	slices.Sort(sizes)
+	stats.MeanSize = float64(sum) / float64(len(sizes))
+	middle := len(sizes) / 2
+	if len(sizes)%2 == 1 {
+		stats.MedianSize = float64(sizes[middle])
+	} else {
+		stats.MedianSize = float64(sizes[middle-1]+sizes[middle]) / 2
+	}
+	return stats
+}
@@ -0,0 +1,128 @@
+package cycles_alg
+
+import (
+	"cycles/data_structs"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Verification holds the results of the checks of a minimum cycle basis, the
+// error of a check is nil when it has passed.
+type Verification struct {
+	// Simple fails when a cycle is not a closed simple cycle of the graph
+	Simple error
+	// Independent fails when the incidence vectors of the cycles are
+	// dependent over GF(2)
+	Independent error
+	// Count fails when the number of the cycles is not the cyclomatic number
+	Count error
+	// Minimal fails when the total weight of the cycles is not the one of
+	// the reference basis of Horton's algorithm
+	Minimal error
+	// ReferenceWeight is the weight of the reference basis
+	ReferenceWeight float64
+}
+
+// Err joins the errors of the failed checks.
+func (verification *Verification) Err() error {
+	return errors.Join(verification.Simple, verification.Independent, verification.Count, verification.Minimal)
+}
+
+// Verify checks that the cycles of the result are a minimum cycle basis of
+// the graph by the weight of the options. The result must be the one of
+// ModeBasis or ModeSSSR with no cycles left out. The reference is calculated
+// independently of the result, so it is slow for large graphs.
+func Verify(graphJson *GraphJson, result *Result, options Options) (*Verification, error) {
+	options, err := options.withMode()
+	if err != nil {
+		return nil, err
+	}
+	if graphJson, err = graphJson.withGraph(); err != nil {
+		return nil, err
+	}
+	data := makeData(graphJson)
+	if data.weights, err = getEdgeWeights(options.Weight, data.points, data.edges, options.Box); err != nil {
+		return nil, err
+	}
+
+	verification := &Verification{}
+	for _, cycle := range result.Cycles {
+		if err := checkCycle(graphJson.Graph, &cycle); err != nil {
+			verification.Simple = fmt.Errorf("the cycle %d is not a simple cycle: %w", cycle.Index, err)
+			break
+		}
+	}
+	if verification.Simple == nil {
+		basis := data_structs.NewGF2Basis()
+		for _, cycle := range result.Cycles {
+			if !basis.Add(turnCycleIntoSupportVector(cycle.Edges, len(data.edges))) {
+				verification.Independent = fmt.Errorf("the cycle %d is a sum of the previous ones", cycle.Index)
+				break
+			}
+		}
+	}
+	edgesCount := 0
+	for _, edge := range data.edges {
+		if edge != nil {
+			edgesCount++
+		}
+	}
+	cyclomaticNumber := edgesCount - len(data.points) + data.componentsCount
+	if len(result.Cycles) != cyclomaticNumber || result.CyclomaticNumber != cyclomaticNumber {
+		verification.Count = fmt.Errorf("expected %d cycles, got %d of the cyclomatic number %d",
+			cyclomaticNumber, len(result.Cycles), result.CyclomaticNumber)
+	}
+
+	if verification.Simple != nil {
+		return verification, nil
+	}
+	weight, heaviest := 0.0, 0.0
+	for _, cycle := range result.Cycles {
+		cycleWeight := getCycleWeight(data.weights, cycle.Edges)
+		weight += cycleWeight
+		heaviest = max(heaviest, cycleWeight)
+	}
+	// No cycle of a minimum cycle basis is heavier than the heaviest cycle of
+	// a basis, so it bounds the reference once the cycles are a basis
+	bound := math.Inf(1)
+	if verification.Independent == nil && verification.Count == nil {
+		bound = heaviest
+	}
+	for _, cycle := range getHortonCycles(data, bound, 0) {
+		verification.ReferenceWeight += getCycleWeight(data.weights, cycle)
+	}
+	if !data_structs.IsSameWeight(weight, verification.ReferenceWeight) {
+		verification.Minimal = fmt.Errorf("the cycles weigh %v, the reference basis weighs %v", weight, verification.ReferenceWeight)
+	} else if !data_structs.IsSameWeight(result.TotalWeight, weight) {
+		verification.Minimal = fmt.Errorf("the total weight is %v, the cycles weigh %v", result.TotalWeight, weight)
+	}
+	return verification, nil
+}
+
+// checkCycle checks that the edge i of the cycle connects its points i and
+// i+1 in the graph and that the points are different.
+func checkCycle(graph data_structs.Graph, cycle *Cycle) error {
+	if len(cycle.Edges) < 3 || len(cycle.Points) != len(cycle.Edges) {
+		return fmt.Errorf("%d points and %d edges", len(cycle.Points), len(cycle.Edges))
+	}
+	points := make(map[int]bool, len(cycle.Points))
+	for i, point := range cycle.Points {
+		if point.PointID < 0 || point.PointID >= len(graph) {
+			return fmt.Errorf("the point %d is not in the graph", point.PointID)
+		}
+		if points[point.PointID] {
+			return fmt.Errorf("the point %d is passed twice", point.PointID)
+		}
+		points[point.PointID] = true
+		next := cycle.Points[(i+1)%len(cycle.Points)].PointID
+		if next < 0 || next >= len(graph) {
+			return fmt.Errorf("the point %d is not in the graph", next)
+		}
+		edge := graph.GetEdge(point.PointID, next)
+		if edge == nil || cycle.Edges[i] == nil || cycle.Edges[i].Number != edge.Number {
+			return fmt.Errorf("the edge %d does not connect the points %d and %d", i, point.PointID, next)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package cycles_alg
+
+import (
+	"bufio"
+	"cycles/types"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// EdgeWeight gives the weight of a bond the basis is minimal by. The weights
+// must be positive. The box is nil when the system is not periodic.
+type EdgeWeight interface {
+	GetWeight(edge *types.Edge, points []*types.Point, box *types.Box) float64
+}
+
+// EdgeWeightFunc lets an ordinary function be used as an EdgeWeight
+type EdgeWeightFunc func(edge *types.Edge, points []*types.Point, box *types.Box) float64
+
+func (f EdgeWeightFunc) GetWeight(edge *types.Edge, points []*types.Point, box *types.Box) float64 {
+	return f(edge, points, box)
+}
+
+// UnitWeight makes the basis minimal by the bond count
+type UnitWeight struct{}
+
+func (UnitWeight) GetWeight(edge *types.Edge, points []*types.Point, box *types.Box) float64 {
+	return edge.Len()
+}
+
+// EuclideanWeight weighs a bond by the length of its minimum image
+type EuclideanWeight struct{}
+
+func (EuclideanWeight) GetWeight(edge *types.Edge, points []*types.Point, box *types.Box) float64 {
+	return box.GetDistance(points[edge.Edge[0]], points[edge.Edge[1]])
+}
+
+// BondTypeWeight weighs a bond by its type, the types missing in the table
+// weigh Default.
+type BondTypeWeight struct {
+	Weights map[int]float64
+	Default float64
+}
+
+func (w BondTypeWeight) GetWeight(edge *types.Edge, points []*types.Point, box *types.Box) float64 {
+	if weight, ok := w.Weights[edge.Type]; ok {
+		return weight
+	}
+	return w.Default
+}
+
+// BondOrderWeight weighs a bond by the inverse of its order looked up by the
+// bond type, so that multiple bonds are shorter than single ones. The types
+// missing in the table are single bonds.
+type BondOrderWeight struct {
+	Orders map[int]float64
+}
+
+func (w BondOrderWeight) GetWeight(edge *types.Edge, points []*types.Point, box *types.Box) float64 {
+	if order, ok := w.Orders[edge.Type]; ok {
+		return 1 / order
+	}
+	return 1
+}
+
+// tieBreakScale keeps the secondary weight of a cycle below one unit of the
+// primary weight as long as the secondary weight of the cycle is below a
+// million.
+const tieBreakScale = 1e-6
+
+// TieBreakWeight makes the basis minimal by Primary, and Secondary decides
+// between the cycles of the same Primary weight. Primary is expected to take
+// integer values like UnitWeight.
+type TieBreakWeight struct {
+	Primary, Secondary EdgeWeight
+}
+
+func (w TieBreakWeight) GetWeight(edge *types.Edge, points []*types.Point, box *types.Box) float64 {
+	return w.Primary.GetWeight(edge, points, box) + tieBreakScale*w.Secondary.GetWeight(edge, points, box)
+}
+
+var edgeWeightNames = map[string]EdgeWeight{
+	"unit":           UnitWeight{},
+	"euclidean":      EuclideanWeight{},
+	"unit-euclidean": TieBreakWeight{Primary: UnitWeight{}, Secondary: EuclideanWeight{}},
+}
+
+// GetEdgeWeight returns the built-in weight by its name: unit, euclidean or
+// unit-euclidean.
+func GetEdgeWeight(name string) (EdgeWeight, error) {
+	if weight, ok := edgeWeightNames[name]; ok {
+		return weight, nil
+	}
+	return nil, fmt.Errorf("unknown weight %q", name)
+}
+
+// LoadWeightTable reads a table of "<bond type> <value>" lines. Empty lines
+// and everything after # are skipped.
+func LoadWeightTable(path string) (map[int]float64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	table := make(map[int]float64)
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected a bond type and a value", path, lineNumber)
+		}
+		bondType, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+		}
+		value, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+		}
+		table[bondType] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+// getEdgeWeights returns the weights of the edges indexed by their numbers.
+func getEdgeWeights(weight EdgeWeight, points []*types.Point, edges []*types.Edge, box *types.Box) ([]float64, error) {
+	weights := make([]float64, len(edges))
+	for _, edge := range edges {
+		if edge == nil {
+			continue
+		}
+		w, err := getEdgeWeight(weight, edge, points, box)
+		if err != nil {
+			return nil, err
+		}
+		weights[edge.Number] = w
+	}
+	return weights, nil
+}
+
+func getEdgeWeight(weight EdgeWeight, edge *types.Edge, points []*types.Point, box *types.Box) (float64, error) {
+	if weight == nil {
+		weight = UnitWeight{}
+	}
+	w := weight.GetWeight(edge, points, box)
+	if !(w > 0) || math.IsInf(w, 1) {
+		return 0, fmt.Errorf("edge %d has the weight %v, weights must be positive", edge.Number, w)
+	}
+	return w, nil
+}
@@ -0,0 +1,50 @@
+package data_structs
+
+import (
+	"cycles/types"
+	"iter"
+)
+
+// DoubledGraph is a two-layer view over a graph. The point i of the graph
+// has the twins i and len(graph)+i. An edge marked in the support vector
+// connects the layers, any other edge stays inside its layer.
+type DoubledGraph struct {
+	graph         Graph
+	supportVector types.SupportVector
+}
+
+func NewDoubledGraph(graph Graph, supportVector types.SupportVector) *DoubledGraph {
+	return &DoubledGraph{
+		graph:         graph,
+		supportVector: supportVector,
+	}
+}
+
+func (doubled *DoubledGraph) Len() int {
+	return 2 * len(doubled.graph)
+}
+
+func (doubled *DoubledGraph) GetTwin(point int) int {
+	originSize := len(doubled.graph)
+	if point < originSize {
+		return point + originSize
+	}
+	return point - originSize
+}
+
+func (doubled *DoubledGraph) Neighbours(point int) iter.Seq[Adjacency] {
+	return func(yield func(Adjacency) bool) {
+		originSize := len(doubled.graph)
+		originPoint := point % originSize
+		shift := point - originPoint
+		for _, adjacency := range doubled.graph[originPoint] {
+			next := shift + adjacency.Point
+			if doubled.supportVector.Test(adjacency.Edge.Number) {
+				next = doubled.GetTwin(next)
+			}
+			if !yield(Adjacency{Point: next, Edge: adjacency.Edge}) {
+				return
+			}
+		}
+	}
+}
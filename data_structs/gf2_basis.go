@@ -0,0 +1,57 @@
+package data_structs
+
+import (
+	"cycles/types"
+	"slices"
+)
+
+// GF2Basis is a set of independent GF(2) vectors kept in the reduced row
+// echelon form: the pivot of every row is its smallest set bit and no other
+// row has this bit set. Reducing a vector by the rows gives the same result
+// for all the vectors that differ by a sum of the rows.
+type GF2Basis struct {
+	rows   []types.SupportVector
+	pivots []int
+}
+
+func NewGF2Basis() *GF2Basis {
+	return &GF2Basis{}
+}
+
+func (basis *GF2Basis) Len() int {
+	return len(basis.rows)
+}
+
+// Reduce returns the vector with the pivots of all the rows cleared, it is
+// zero when the vector is in the span of the rows.
+func (basis *GF2Basis) Reduce(vector types.SupportVector) types.SupportVector {
+	reduced := slices.Clone(vector)
+	for i, row := range basis.rows {
+		if reduced.Test(basis.pivots[i]) {
+			reduced.XORInPlace(row)
+		}
+	}
+	return reduced
+}
+
+// Add adds the vector unless it is in the span of the rows and tells whether
+// it was added.
+func (basis *GF2Basis) Add(vector types.SupportVector) bool {
+	reduced := basis.Reduce(vector)
+	pivot := reduced.GetFirst()
+	if pivot == -1 {
+		return false
+	}
+	for _, row := range basis.rows {
+		if row.Test(pivot) {
+			row.XORInPlace(reduced)
+		}
+	}
+	basis.rows = append(basis.rows, reduced)
+	basis.pivots = append(basis.pivots, pivot)
+	return true
+}
+
+func (basis *GF2Basis) Contains(vector types.SupportVector) bool {
+	return basis.Reduce(vector).GetFirst() == -1
+}
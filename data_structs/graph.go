@@ -1,22 +1,73 @@
 package data_structs
 
-import "cycles/types"
+import (
+	"cycles/types"
+	"slices"
+)
 
-type Graph [][]*types.Edge
+type Adjacency struct {
+	Point int
+	Edge  *types.Edge
+}
+
+// Graph is an adjacency list: graph[i] holds the neighbours of the point i
+// sorted by their numbers, so the memory grows with the number of edges.
+type Graph [][]Adjacency
+
+func NewGraph(pointsCount int) Graph {
+	return make(Graph, pointsCount)
+}
+
+func (graph *Graph) Connect(i, j int, edge *types.Edge) {
+	graph.insert(i, j, edge)
+	graph.insert(j, i, edge)
+}
+
+func (graph *Graph) Disconnect(i, j int) {
+	graph.remove(i, j)
+	graph.remove(j, i)
+}
+
+func (graph *Graph) GetEdge(i, j int) *types.Edge {
+	row := (*graph)[i]
+	if pos, found := slices.BinarySearchFunc(row, j, compareAdjacency); found {
+		return row[pos].Edge
+	}
+	return nil
+}
 
 func (graph *Graph) IsConnected(i, j int) bool {
-	return (*graph)[i][j] != nil
+	return graph.GetEdge(i, j) != nil
 }
 
 func (graph *Graph) GetOnlyNumbers() [][]int {
 	onlyNumbers := make([][]int, len(*graph))
-	for i := 0; i < len(onlyNumbers); i++ {
-		onlyNumbers[i] = make([]int, len((*graph)[i]))
-		for j := 0; j < len(onlyNumbers[i]); j++ {
-			if (*graph)[i][j] != nil {
-				onlyNumbers[i][j] = 1
-			}
+	for i, row := range *graph {
+		onlyNumbers[i] = make([]int, len(row))
+		for j, adjacency := range row {
+			onlyNumbers[i][j] = adjacency.Point
 		}
 	}
 	return onlyNumbers
 }
+
+func (graph *Graph) insert(from, to int, edge *types.Edge) {
+	row := (*graph)[from]
+	pos, found := slices.BinarySearchFunc(row, to, compareAdjacency)
+	if found {
+		row[pos].Edge = edge
+		return
+	}
+	(*graph)[from] = slices.Insert(row, pos, Adjacency{Point: to, Edge: edge})
+}
+
+func (graph *Graph) remove(from, to int) {
+	row := (*graph)[from]
+	if pos, found := slices.BinarySearchFunc(row, to, compareAdjacency); found {
+		(*graph)[from] = slices.Delete(row, pos, pos+1)
+	}
+}
+
+func compareAdjacency(adjacency Adjacency, point int) int {
+	return adjacency.Point - point
+}
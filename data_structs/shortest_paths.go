@@ -0,0 +1,228 @@
+package data_structs
+
+import (
+	"container/heap"
+	"cycles/types"
+	"iter"
+	"math"
+	"slices"
+)
+
+// WeightTolerance keeps the rounding errors of the sums of the weights from
+// telling equal weights apart
+const WeightTolerance = 1e-9
+
+func IsSameWeight(w1, w2 float64) bool {
+	if math.IsInf(w1, 0) || math.IsInf(w2, 0) {
+		return w1 == w2
+	}
+	return math.Abs(w1-w2) <= WeightTolerance*max(1, math.Abs(w1), math.Abs(w2))
+}
+
+// PathFamily is a set of cycles following Vismara: the shortest paths from
+// the root to the two ends closed by the link, either the edge between the
+// ends or the two edges from the ends to a common point. The root is the
+// largest point of the cycles.
+type PathFamily struct {
+	Root   int
+	Ends   [2]int
+	Link   []*types.Edge
+	Weight float64
+}
+
+// ShortestPaths is the graph of the shortest paths from the root that pass
+// only through the points smaller than the root. The arrays are reused from
+// root to root and only the touched points are reset.
+type ShortestPaths struct {
+	graph   Graph
+	weights []float64
+	root    int
+	lengths []float64
+	// preds are the previous steps of the shortest paths through the smaller
+	// points
+	preds   [][]Adjacency
+	settled []bool
+	// allowed points are the root and the smaller points reached by a
+	// shortest path through the smaller points
+	allowed []bool
+	touched []int
+}
+
+func NewShortestPaths(graph Graph, weights []float64) *ShortestPaths {
+	paths := &ShortestPaths{
+		graph:   graph,
+		weights: weights,
+		lengths: make([]float64, len(graph)),
+		preds:   make([][]Adjacency, len(graph)),
+		settled: make([]bool, len(graph)),
+		allowed: make([]bool, len(graph)),
+	}
+	for i := range paths.lengths {
+		paths.lengths[i] = math.Inf(1)
+	}
+	return paths
+}
+
+// GetLength returns the length of the shortest path from the root to the
+// point, it is infinite beyond the bound.
+func (paths *ShortestPaths) GetLength(point int) float64 {
+	return paths.lengths[point]
+}
+
+func (paths *ShortestPaths) reset() {
+	for _, point := range paths.touched {
+		paths.lengths[point] = math.Inf(1)
+		paths.preds[point] = paths.preds[point][:0]
+		paths.settled[point] = false
+		paths.allowed[point] = false
+	}
+	paths.touched = paths.touched[:0]
+}
+
+// Calculate runs Dijkstra from the root up to the bound. The lengths are the
+// ones of the whole graph, the larger points only make the smaller ones not
+// allowed.
+func (paths *ShortestPaths) Calculate(root int, bound float64) {
+	paths.reset()
+	paths.root = root
+	paths.lengths[root] = 0
+	paths.touched = append(paths.touched, root)
+	pq := &PriorityQueue{PQItem{Dist: 0, Num: root}}
+	heap.Init(pq)
+	for pq.Len() > 0 {
+		it := heap.Pop(pq).(PQItem)
+		if paths.settled[it.Num] {
+			continue
+		}
+		paths.settled[it.Num] = true
+		paths.allowed[it.Num] = it.Num == root || (it.Num < root && len(paths.preds[it.Num]) > 0)
+		for _, adjacency := range paths.graph[it.Num] {
+			point := adjacency.Point
+			newDist := it.Dist + paths.weights[adjacency.Edge.Number]
+			if paths.settled[point] || (newDist > bound && !IsSameWeight(newDist, bound)) {
+				continue
+			}
+			if math.IsInf(paths.lengths[point], 1) {
+				paths.touched = append(paths.touched, point)
+			}
+			step := Adjacency{Point: it.Num, Edge: adjacency.Edge}
+			if IsSameWeight(newDist, paths.lengths[point]) {
+				if paths.allowed[it.Num] {
+					paths.preds[point] = append(paths.preds[point], step)
+				}
+			} else if newDist < paths.lengths[point] {
+				paths.lengths[point] = newDist
+				paths.preds[point] = paths.preds[point][:0]
+				if paths.allowed[it.Num] {
+					paths.preds[point] = append(paths.preds[point], step)
+				}
+				heap.Push(pq, PQItem{Dist: newDist, Num: point})
+			}
+		}
+	}
+}
+
+// GetFamilies returns the families of the root not heavier than the bound.
+// The middle of a cycle of a family is either inside the link edge or at the
+// common point of the two link edges.
+func (paths *ShortestPaths) GetFamilies(bound float64) []PathFamily {
+	families := make([]PathFamily, 0)
+	for _, point := range paths.touched {
+		if !paths.allowed[point] {
+			continue
+		}
+		for _, adjacency := range paths.graph[point] {
+			other := adjacency.Point
+			if other <= point || !paths.allowed[other] {
+				continue
+			}
+			weight := paths.weights[adjacency.Edge.Number]
+			if IsSameWeight(paths.lengths[point]+weight, paths.lengths[other]) ||
+				IsSameWeight(paths.lengths[other]+weight, paths.lengths[point]) {
+				continue
+			}
+			f := PathFamily{
+				Root:   paths.root,
+				Ends:   [2]int{point, other},
+				Link:   []*types.Edge{adjacency.Edge},
+				Weight: paths.lengths[point] + weight + paths.lengths[other],
+			}
+			if f.Weight <= bound || IsSameWeight(f.Weight, bound) {
+				families = append(families, f)
+			}
+		}
+		if point == paths.root {
+			continue
+		}
+		preds := paths.preds[point]
+		weight := 2 * paths.lengths[point]
+		if weight > bound && !IsSameWeight(weight, bound) {
+			continue
+		}
+		for i := range preds {
+			for j := i + 1; j < len(preds); j++ {
+				families = append(families, PathFamily{
+					Root:   paths.root,
+					Ends:   [2]int{preds[i].Point, preds[j].Point},
+					Link:   []*types.Edge{preds[i].Edge, preds[j].Edge},
+					Weight: weight,
+				})
+			}
+		}
+	}
+	return families
+}
+
+// getPaths yields the shortest paths from the root to the point as the steps
+// after the root.
+func (paths *ShortestPaths) getPaths(point int) iter.Seq[[]Adjacency] {
+	return func(yield func([]Adjacency) bool) {
+		paths.yieldPaths(point, nil, yield)
+	}
+}
+
+// yieldPaths goes back from the point to the root, the tail is the part of the
+// path after the point
+func (paths *ShortestPaths) yieldPaths(point int, tail []Adjacency, yield func([]Adjacency) bool) bool {
+	if point == paths.root {
+		path := slices.Clone(tail)
+		slices.Reverse(path)
+		return yield(path)
+	}
+	for _, pred := range paths.preds[point] {
+		if !paths.yieldPaths(pred.Point, append(tail, Adjacency{Point: point, Edge: pred.Edge}), yield) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetCycles yields the cycles of the family, the pairs of the paths to its
+// ends that meet only at the root. The family must be one of the root the
+// paths are calculated for.
+func (paths *ShortestPaths) GetCycles(f PathFamily) iter.Seq[[]*types.Edge] {
+	return func(yield func([]*types.Edge) bool) {
+		for path := range paths.getPaths(f.Ends[0]) {
+			points := make(map[int]bool, len(path))
+			for _, step := range path {
+				points[step.Point] = true
+			}
+			for otherPath := range paths.getPaths(f.Ends[1]) {
+				if slices.ContainsFunc(otherPath, func(step Adjacency) bool { return points[step.Point] }) {
+					continue
+				}
+				cycle := make([]*types.Edge, 0, len(path)+len(f.Link)+len(otherPath))
+				for _, step := range path {
+					cycle = append(cycle, step.Edge)
+				}
+				cycle = append(cycle, f.Link...)
+				for _, step := range slices.Backward(otherPath) {
+					cycle = append(cycle, step.Edge)
+				}
+				if !yield(cycle) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -1,20 +1,31 @@
 package data_structs
 
+import "errors"
+
+// ErrEmptyStack is the error of taking an element of an empty stack
+var ErrEmptyStack = errors.New("the stack is empty")
+
 type Stack[T any] []T
 
 func (stack *Stack[T]) Push(p T) {
 	*stack = append(*stack, p)
 }
 
-func (stack *Stack[T]) Pop() T {
-	last := stack.last()
-	p := (*stack)[last]
-	*stack = (*stack)[:last]
-	return p
+func (stack *Stack[T]) Pop() (T, error) {
+	p, err := stack.Pick()
+	if err != nil {
+		return p, err
+	}
+	*stack = (*stack)[:stack.last()]
+	return p, nil
 }
 
-func (stack *Stack[T]) Pick() T {
-	return (*stack)[stack.last()]
+func (stack *Stack[T]) Pick() (T, error) {
+	if stack.IsEmpty() {
+		var zero T
+		return zero, ErrEmptyStack
+	}
+	return (*stack)[stack.last()], nil
 }
 
 func (stack *Stack[T]) IsEmpty() bool {
@@ -2,59 +2,314 @@ package main
 
 import (
 	"cycles/cycles_alg"
+	"cycles/primitive_alg"
+	"cycles/types"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
-	"strconv"
+	"slices"
 	"strings"
 
 	deserializer "github.com/Ivanestver/lammps-file-parser/deserialize"
 )
 
+// config holds the parameters shared by all the modes
+type config struct {
+	infile        string
+	workers       int
+	weight        string
+	weightsFile   string
+	defaultWeight float64
+	boundary      string
+	winding       string
+	mode          string
+	algorithm     string
+	maxRingSize   int
+	format        string
+	out           string
+}
+
+func (c *config) addFlags(flags *flag.FlagSet, formats []string) {
+	flags.StringVar(&c.infile, "infile", "", "Specifies the input file")
+	flags.IntVar(&c.workers, "workers", 0, "Specifies the number of workers searching for cycles, all CPUs are used by default")
+	flags.StringVar(&c.weight, "weight", "unit", "Specifies the bond weights: unit, euclidean, unit-euclidean, type or order")
+	flags.StringVar(&c.weightsFile, "weights-file", "", "Specifies the table of bond weights or orders by bond type for the type and order weights")
+	flags.Float64Var(&c.defaultWeight, "default-weight", 1, "Specifies the weight of the bond types missing in the weights file for the type weight")
+	flags.StringVar(&c.boundary, "boundary", "fff", "Specifies the LAMMPS boundary style, p for a periodic dimension and f for a non-periodic one")
+	flags.StringVar(&c.winding, "winding", "include", "Specifies what to do with cycles winding around the periodic box: include, exclude or separate")
+	flags.StringVar(&c.mode, "mode", "basis", "Specifies the cycles to find: basis for a minimum cycle basis, relevant for the union of all of them, sssr for the basis of the unit weights, essr for the relevant cycles of the unit weights, urf for a cycle of every unique ring family or primitive for the rings without shortcuts")
+	flags.StringVar(&c.algorithm, "algorithm", "depina", "Specifies the minimum cycle basis algorithm: depina or horton, which can be faster for small dense graphs")
+	flags.IntVar(&c.maxRingSize, "max-ring-size", 0, "Specifies the largest ring size in atoms, the larger basis cycles are cut and reported, the primitive mode requires it")
+	flags.StringVar(&c.format, "format", formats[0], fmt.Sprintf("Specifies the output format: %s", strings.Join(formats, ", ")))
+	flags.StringVar(&c.out, "out", "", "Specifies the output file, the standard output is used by default")
+}
+
 func main() {
-	infilePtr := flag.String("infile", "", "Specifies the input file")
-	flag.Parse()
-	if len(*infilePtr) == 0 {
-		fmt.Println("Wrong usage of the infile parameter")
-		return
+	if len(os.Args) > 1 && os.Args[1] == "stats" {
+		os.Exit(runStats(os.Args[2:], os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "trajectory" {
+		os.Exit(runTrajectory(os.Args[2:], os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		os.Exit(runVerify(os.Args[2:], os.Stderr))
+	}
+	os.Exit(runCycles(os.Args[1:], os.Stderr))
+}
+
+var cyclesFormats = []string{"text", "json", "csv", "ndjson"}
+
+// runCycles writes the cycles of the data in the format. It returns the exit
+// status: 1 on an error, which goes to stderr, and 2 on wrong flags.
+func runCycles(args []string, stderr io.Writer) int {
+	c := config{}
+	flags := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	c.addFlags(flags, cyclesFormats)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if !slices.Contains(cyclesFormats, c.format) {
+		fmt.Fprintln(stderr, "Wrong usage of the format parameter")
+		return 2
+	}
+	result, err := c.calculateCycles()
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+	err = c.writeOutput(func(w io.Writer) error {
+		return writeResult(w, result, c.format, c.winding == "separate")
+	})
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+	return 0
+}
+
+var statsFormats = []string{"table", "json"}
+
+// runStats reports the ring size distribution of the basis instead of the
+// rings themselves. It returns the exit status as runCycles does.
+func runStats(args []string, stderr io.Writer) int {
+	c := config{}
+	flags := flag.NewFlagSet(os.Args[0]+" stats", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	c.addFlags(flags, statsFormats)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if !slices.Contains(statsFormats, c.format) {
+		fmt.Fprintln(stderr, "Wrong usage of the format parameter")
+		return 2
 	}
-	content, err := os.ReadFile(*infilePtr)
+	result, err := c.calculateCycles()
 	if err != nil {
-		fmt.Println(err.Error())
-		return
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+	err = c.writeOutput(func(w io.Writer) error {
+		return writeStats(w, result.GetStats(), c.format)
+	})
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+	return 0
+}
+
+// runVerify checks the basis of the data against the reference one. It
+// returns the exit status: 1 when a check fails or on an error, which goes to
+// stderr, and 2 on wrong flags.
+func runVerify(args []string, stderr io.Writer) int {
+	c := config{}
+	flags := flag.NewFlagSet(os.Args[0]+" verify", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	c.addFlags(flags, []string{"text"})
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if err := c.checkVerifiable(); err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 2
+	}
+	verification, result, err := c.verify()
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+	err = c.writeOutput(func(w io.Writer) error {
+		writeVerification(w, verification, result)
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+	if verification.Err() != nil {
+		return 1
+	}
+	return 0
+}
+
+// checkVerifiable rejects the parameters making a result that is not a whole
+// minimum cycle basis, which is the only one Verify checks
+func (c *config) checkVerifiable() error {
+	switch {
+	case c.format != "text":
+		return errors.New("wrong usage of the format parameter")
+	case c.mode != string(cycles_alg.ModeBasis) && c.mode != string(cycles_alg.ModeSSSR):
+		return errors.New("the verify mode checks only the basis and sssr modes")
+	case c.maxRingSize > 0:
+		return errors.New("the verify mode checks only the whole basis, without the max-ring-size parameter")
+	case c.winding != "include":
+		return errors.New("the verify mode checks only the whole basis, with the winding parameter include")
+	}
+	return nil
+}
+
+// verify calculates the cycles of the data and checks them
+func (c *config) verify() (*cycles_alg.Verification, *cycles_alg.Result, error) {
+	options, err := c.getOptions()
+	if err != nil {
+		return nil, nil, err
+	}
+	graphJson, box, err := c.readData()
+	if err != nil {
+		return nil, nil, err
+	}
+	options.Box = box
+	result, err := c.calculateCyclesOfGraph(graphJson, options)
+	if err != nil {
+		return nil, nil, err
+	}
+	verification, err := cycles_alg.Verify(graphJson, result, options)
+	if err != nil {
+		return nil, nil, err
+	}
+	return verification, result, nil
+}
+
+func (c *config) calculateCycles() (*cycles_alg.Result, error) {
+	options, err := c.getOptions()
+	if err != nil {
+		return nil, err
+	}
+	graphJson, box, err := c.readData()
+	if err != nil {
+		return nil, err
+	}
+	options.Box = box
+	return c.calculateCyclesOfGraph(graphJson, options)
+}
+
+// calculateCyclesOfGraph runs the algorithm of the mode, the primitive rings
+// have their own package
+func (c *config) calculateCyclesOfGraph(graphJson *cycles_alg.GraphJson, options cycles_alg.Options) (*cycles_alg.Result, error) {
+	if c.mode == "primitive" {
+		return primitive_alg.CalculateRings(graphJson, primitive_alg.Options{
+			Workers:        options.Workers,
+			MaxSize:        options.MaxRingSize,
+			Box:            options.Box,
+			ExcludeWinding: options.ExcludeWinding,
+		})
+	}
+	return cycles_alg.CalculateCyclesOfGraph(graphJson, options)
+}
+
+// getOptions returns the options of the algorithm except the box
+func (c *config) getOptions() (cycles_alg.Options, error) {
+	weight, err := getEdgeWeight(c.weight, c.weightsFile, c.defaultWeight)
+	if err != nil {
+		return cycles_alg.Options{}, err
+	}
+	if !slices.Contains([]string{"include", "exclude", "separate"}, c.winding) {
+		return cycles_alg.Options{}, errors.New("wrong usage of the winding parameter")
+	}
+	return cycles_alg.Options{
+		Workers:        c.workers,
+		Weight:         weight,
+		ExcludeWinding: c.winding == "exclude",
+		Mode:           cycles_alg.Mode(c.mode),
+		MaxRingSize:    c.maxRingSize,
+		Algorithm:      cycles_alg.Algorithm(c.algorithm),
+	}, nil
+}
+
+// readData reads the graph of the data file and its box when the boundary
+// parameter makes it periodic
+func (c *config) readData() (*cycles_alg.GraphJson, *types.Box, error) {
+	if len(c.infile) == 0 {
+		return nil, nil, errors.New("wrong usage of the infile parameter")
+	}
+	periodic, err := parseBoundary(c.boundary)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := os.ReadFile(c.infile)
+	if err != nil {
+		return nil, nil, err
 	}
 	lammpsStruct, err := deserializer.Deserialize(
 		string(content),
-		*infilePtr)
+		c.infile)
 	if err != nil {
-		fmt.Println(err.Error())
-		return
+		return nil, nil, err
+	}
+	var box *types.Box
+	if periodic != [3]bool{} {
+		if box, err = parseBox(string(content), periodic); err != nil {
+			return nil, nil, err
+		}
 	}
 	jsonObj, err := json.Marshal(*lammpsStruct)
 	if err != nil {
-		fmt.Println(err.Error())
-		return
+		return nil, nil, err
 	}
+	graphJson, err := cycles_alg.ParseGraphJson(string(jsonObj))
+	if err != nil {
+		return nil, nil, err
+	}
+	return graphJson, box, nil
+}
 
-	cycles, err := cycles_alg.CalculateCycles(string(jsonObj))
+// writeOutput writes to the output of the out parameter. The file is closed
+// here since a failed write may show only when it is closed.
+func (c *config) writeOutput(write func(w io.Writer) error) error {
+	if len(c.out) == 0 {
+		return write(os.Stdout)
+	}
+	out, err := os.Create(c.out)
 	if err != nil {
-		fmt.Println(err.Error())
-		return
+		return err
 	}
-	printCycles(cycles)
+	if err := write(out); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
 }
 
-func printCycles(cycles []cycles_alg.Cycle) {
-	for i, cycle := range cycles {
-		builder := strings.Builder{}
-		builder.WriteString("C")
-		builder.WriteString(strconv.Itoa(i))
-		builder.WriteString(": ")
-		for _, edge := range cycle {
-			builder.WriteString(fmt.Sprintf("%v, ", edge))
-		}
-		builder.WriteString("\n\n")
-		fmt.Println(builder.String())
+// getEdgeWeight returns the weight of the name, the bond types missing in the
+// weights file weigh defaultWeight for the type weight and are single bonds
+// for the order weight.
+func getEdgeWeight(name, weightsFile string, defaultWeight float64) (cycles_alg.EdgeWeight, error) {
+	if name != "type" && name != "order" {
+		return cycles_alg.GetEdgeWeight(name)
+	}
+	if len(weightsFile) == 0 {
+		return nil, fmt.Errorf("the %s weight requires the weights-file parameter", name)
+	}
+	table, err := cycles_alg.LoadWeightTable(weightsFile)
+	if err != nil {
+		return nil, err
+	}
+	if name == "type" {
+		return cycles_alg.BondTypeWeight{Weights: table, Default: defaultWeight}, nil
 	}
+	return cycles_alg.BondOrderWeight{Orders: table}, nil
 }
@@ -0,0 +1,566 @@
+package main
+
+import (
+	"bytes"
+	"cycles/cycles_alg"
+	"cycles/trajectory"
+	"cycles/types"
+	"fmt"
+	"iter"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// testData is a square 1-2-3-4 and a triangle 3-4-5 sharing the bond 3
+const testData = `LAMMPS data file
+
+5 atoms
+2 atom types
+6 bonds
+2 bond types
+
+0 10 xlo xhi
+0 10 ylo yhi
+0 10 zlo zhi
+
+Masses
+
+1 12
+2 14
+
+Bond Coeffs # harmonic
+
+1 300 1.5
+2 300 1.4
+
+Atoms # full
+
+1 1 1 0 1 1 5 0 0 0
+2 1 1 0 3 1 5 0 0 0
+3 1 1 0 3 3 5 0 0 0
+4 1 2 0 1 3 5 0 0 0
+5 1 2 0 2 4 5 0 0 0
+
+Bonds
+
+1 1 1 2
+2 1 2 3
+3 1 3 4
+4 1 4 1
+5 2 3 5
+6 2 5 4
+`
+
+// writeTestData writes the content to a file of a temporary directory and
+// returns its path
+func writeTestData(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestGetEdgeWeight(t *testing.T) {
+	path := writeTestData(t, "weights.txt", "1 2.5\n")
+	tests := []struct {
+		name          string
+		defaultWeight float64
+		bondType      int
+		expected      float64
+	}{
+		{"type", 1, 1, 2.5},
+		{"type", 1, 2, 1},
+		{"type", 3, 2, 3},
+		{"order", 1, 1, 0.4},
+		{"order", 3, 2, 1},
+	}
+	for _, test := range tests {
+		weight, err := getEdgeWeight(test.name, path, test.defaultWeight)
+		if err != nil {
+			t.Fatal(err)
+		}
+		edge := &types.Edge{Edge: [2]int{0, 1}, Type: test.bondType}
+		if real := weight.GetWeight(edge, nil, nil); real != test.expected {
+			t.Errorf("%s weight of the type %d: expected: %v, got: %v", test.name, test.bondType, test.expected, real)
+		}
+	}
+	if _, err := getEdgeWeight("type", "", 1); err == nil {
+		t.Error("Expected an error for the missing weights file")
+	}
+}
+
+func TestRunVerify(t *testing.T) {
+	infile := writeTestData(t, "square.data", testData)
+	out := filepath.Join(t.TempDir(), "verification.txt")
+	tests := []struct {
+		name   string
+		args   []string
+		status int
+		stderr string
+	}{
+		{"verified", []string{"-infile", infile, "-out", out}, 0, ""},
+		{"missing infile", []string{"-out", out}, 1, "wrong usage of the infile parameter\n"},
+		{"unreadable infile", []string{"-infile", infile + ".missing", "-out", out}, 1, "no such file"},
+		{"wrong weight", []string{"-infile", infile, "-weight", "mass", "-out", out}, 1, "unknown weight \"mass\"\n"},
+		{"wrong flag", []string{"-infile", infile, "-size", "3"}, 2, "flag provided but not defined: -size"},
+		{"wrong format", []string{"-infile", infile, "-format", "json"}, 2, "wrong usage of the format parameter\n"},
+		{"relevant mode", []string{"-infile", infile, "-mode", "relevant"}, 2, "checks only the basis and sssr modes\n"},
+		{"primitive mode", []string{"-infile", infile, "-mode", "primitive", "-max-ring-size", "6"}, 2, "checks only the basis and sssr modes\n"},
+		{"maximum ring size", []string{"-infile", infile, "-max-ring-size", "3"}, 2, "without the max-ring-size parameter\n"},
+		{"excluded winding", []string{"-infile", infile, "-winding", "exclude"}, 2, "with the winding parameter include\n"},
+		{"separate winding", []string{"-infile", infile, "-winding", "separate"}, 2, "with the winding parameter include\n"},
+		{"sssr", []string{"-infile", infile, "-mode", "sssr", "-out", out}, 0, ""},
+	}
+	for _, test := range tests {
+		stderr := &bytes.Buffer{}
+		if status := runVerify(test.args, stderr); status != test.status {
+			t.Errorf("%s: expected the status %d, got: %d", test.name, test.status, status)
+		}
+		if !strings.Contains(stderr.String(), test.stderr) || (len(test.stderr) == 0 && stderr.Len() != 0) {
+			t.Errorf("%s: expected %q in stderr, got: %q", test.name, test.stderr, stderr.String())
+		}
+	}
+	content, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `simple cycles      ok
+independent        ok
+cyclomatic number  ok
+minimal            ok
+
+cycles: 2
+total weight: 7
+reference weight: 7
+`
+	if string(content) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, content)
+	}
+}
+
+func TestRunCycles(t *testing.T) {
+	infile := writeTestData(t, "square.data", testData)
+	tests := []struct {
+		format   string
+		args     []string
+		expected string
+	}{
+		{"text", nil, `C0: &{3 1 3 5 0}, &{2 3 3 5 0}, &{1 3 1 5 0}, &{0 1 1 5 0}, 
+
+
+C1: &{2 3 3 5 0}, &{4 2 4 5 0}, &{3 1 3 5 0}, 
+
+
+Component 0: 2 cycles
+`},
+		{"json", nil, `{
+  "cyclomatic_number": 2,
+  "components": 1,
+  "total_weight": 7,
+  "rings": [
+    {
+      "ring": 1,
+      "size": 4,
+      "atoms": [
+        4,
+        3,
+        2,
+        1
+      ],
+      "bonds": [
+        3,
+        2,
+        1,
+        4
+      ],
+      "weight": 4,
+      "component": 1,
+      "winding": [
+        0,
+        0,
+        0
+      ]
+    },
+    {
+      "ring": 2,
+      "size": 3,
+      "atoms": [
+        3,
+        5,
+        4
+      ],
+      "bonds": [
+        5,
+        6,
+        3
+      ],
+      "weight": 3,
+      "component": 1,
+      "winding": [
+        0,
+        0,
+        0
+      ]
+    }
+  ]
+}
+`},
+		{"csv", nil, `ring,size,position,atom,bond,weight,component,family,family_size,winding_x,winding_y,winding_z
+1,4,1,4,3,4,1,,,0,0,0
+1,4,2,3,2,4,1,,,0,0,0
+1,4,3,2,1,4,1,,,0,0,0
+1,4,4,1,4,4,1,,,0,0,0
+2,3,1,3,5,3,1,,,0,0,0
+2,3,2,5,6,3,1,,,0,0,0
+2,3,3,4,3,3,1,,,0,0,0
+`},
+		{"csv", []string{"-mode", "relevant"}, `ring,size,position,atom,bond,weight,component,family,family_size,winding_x,winding_y,winding_z
+1,3,1,5,5,3,1,1,1,0,0,0
+1,3,2,3,3,3,1,1,1,0,0,0
+1,3,3,4,6,3,1,1,1,0,0,0
+2,4,1,4,4,4,1,2,1,0,0,0
+2,4,2,1,1,4,1,2,1,0,0,0
+2,4,3,2,2,4,1,2,1,0,0,0
+2,4,4,3,3,4,1,2,1,0,0,0
+`},
+		{"ndjson", nil, `{"ring":1,"size":4,"atoms":[4,3,2,1],"bonds":[3,2,1,4],"weight":4,"component":1,"winding":[0,0,0]}
+{"ring":2,"size":3,"atoms":[3,5,4],"bonds":[5,6,3],"weight":3,"component":1,"winding":[0,0,0]}
+`},
+	}
+	for _, test := range tests {
+		out := filepath.Join(t.TempDir(), "rings."+test.format)
+		stderr := &bytes.Buffer{}
+		args := append([]string{"-infile", infile, "-format", test.format, "-out", out}, test.args...)
+		if status := runCycles(args, stderr); status != 0 {
+			t.Fatalf("%s: expected the status 0, got: %d, %s", test.format, status, stderr)
+		}
+		content, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != test.expected {
+			t.Errorf("%s %v: expected:\n%s\ngot:\n%s", test.format, test.args, test.expected, content)
+		}
+	}
+
+	errorTests := []struct {
+		name   string
+		args   []string
+		status int
+		stderr string
+	}{
+		{"wrong format", []string{"-infile", infile, "-format", "xml"}, 2, "Wrong usage of the format parameter\n"},
+		{"missing infile", nil, 1, "wrong usage of the infile parameter\n"},
+		{"unwritable output", []string{"-infile", infile, "-out", filepath.Join(infile, "rings.txt")}, 1, "not a directory"},
+	}
+	for _, test := range errorTests {
+		stderr := &bytes.Buffer{}
+		if status := runCycles(test.args, stderr); status != test.status {
+			t.Errorf("%s: expected the status %d, got: %d", test.name, test.status, status)
+		}
+		if !strings.Contains(stderr.String(), test.stderr) {
+			t.Errorf("%s: expected %q in stderr, got: %q", test.name, test.stderr, stderr.String())
+		}
+	}
+}
+
+var testStats = cycles_alg.Stats{
+	RingsCount:       5,
+	SizeCounts:       map[int]int{5: 1, 6: 3, 8: 1},
+	MeanSize:         6.2,
+	MedianSize:       6,
+	TotalWeight:      31.5,
+	CyclomaticNumber: 6,
+	CutCount:         1,
+}
+
+func TestWriteStats(t *testing.T) {
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"table", `size   count
+5      1
+6      3
+8      1
+
+rings: 5
+mean size: 6.2
+median size: 6
+total weight: 31.5
+cyclomatic number: 6
+cut: 1
+`},
+		{"json", `{
+  "rings": 5,
+  "size_counts": {
+    "5": 1,
+    "6": 3,
+    "8": 1
+  },
+  "mean_size": 6.2,
+  "median_size": 6,
+  "total_weight": 31.5,
+  "cyclomatic_number": 6,
+  "cut": 1
+}
+`},
+	}
+	for _, test := range tests {
+		w := &strings.Builder{}
+		if err := writeStats(w, testStats, test.format); err != nil {
+			t.Fatal(err)
+		}
+		if w.String() != test.expected {
+			t.Errorf("%s: expected:\n%s\ngot:\n%s", test.format, test.expected, w.String())
+		}
+	}
+	if err := writeStats(&strings.Builder{}, testStats, "csv"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+
+	infile := writeTestData(t, "square.data", testData)
+	out := filepath.Join(t.TempDir(), "stats.txt")
+	stderr := &bytes.Buffer{}
+	if status := runStats([]string{"-infile", infile, "-out", out}, stderr); status != 0 {
+		t.Fatalf("Expected the status 0, got: %d, %s", status, stderr)
+	}
+	content, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `size   count
+3      1
+4      1
+
+rings: 2
+mean size: 3.5
+median size: 3.5
+total weight: 7
+cyclomatic number: 2
+`
+	if string(content) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, content)
+	}
+	if status := runStats([]string{"-infile", infile, "-format", "csv"}, stderr); status != 2 {
+		t.Errorf("Expected the status 2 for a wrong format, got: %d", status)
+	}
+}
+
+func TestSeriesWriter(t *testing.T) {
+	frames := []struct {
+		timestep int
+		stats    cycles_alg.Stats
+	}{
+		{0, testStats},
+		{100, cycles_alg.Stats{RingsCount: 1, SizeCounts: map[int]int{6: 1}, MeanSize: 6, MedianSize: 6, TotalWeight: 6, CyclomaticNumber: 1}},
+	}
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"ndjson", `{"timestep":0,"rings":5,"size_counts":{"5":1,"6":3,"8":1},"mean_size":6.2,"median_size":6,"total_weight":31.5,"cyclomatic_number":6,"cut":1}
+{"timestep":100,"rings":1,"size_counts":{"6":1},"mean_size":6,"median_size":6,"total_weight":6,"cyclomatic_number":1}
+`},
+		{"csv", `timestep,size,count
+0,5,1
+0,6,3
+0,8,1
+100,6,1
+`},
+		{"json", `[
+  {
+    "timestep": 0,
+    "rings": 5,
+    "size_counts": {
+      "5": 1,
+      "6": 3,
+      "8": 1
+    },
+    "mean_size": 6.2,
+    "median_size": 6,
+    "total_weight": 31.5,
+    "cyclomatic_number": 6,
+    "cut": 1
+  },
+  {
+    "timestep": 100,
+    "rings": 1,
+    "size_counts": {
+      "6": 1
+    },
+    "mean_size": 6,
+    "median_size": 6,
+    "total_weight": 6,
+    "cyclomatic_number": 1
+  }
+]
+`},
+	}
+	for _, test := range tests {
+		w := &strings.Builder{}
+		writer := newSeriesWriter(w, test.format)
+		for _, frame := range frames {
+			if err := writer.write(frame.timestep, frame.stats); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := writer.close(); err != nil {
+			t.Fatal(err)
+		}
+		if w.String() != test.expected {
+			t.Errorf("%s: expected:\n%s\ngot:\n%s", test.format, test.expected, w.String())
+		}
+	}
+}
+
+// makeAtomDump makes an atom dump of the frames of the atoms along the x axis
+func makeAtomDump(timesteps []int, atomsCount int) string {
+	builder := strings.Builder{}
+	for _, timestep := range timesteps {
+		fmt.Fprintf(&builder, "ITEM: TIMESTEP\n%d\nITEM: NUMBER OF ATOMS\n%d\n", timestep, atomsCount)
+		builder.WriteString("ITEM: BOX BOUNDS pp pp pp\n0 10\n0 10\n0 10\nITEM: ATOMS id type x y z\n")
+		for i := range atomsCount {
+			fmt.Fprintf(&builder, "%d 1 %d 0 0\n", i+1, i)
+		}
+	}
+	return builder.String()
+}
+
+// makeBondDump makes a local dump of the frames of the same 1-based bonds
+func makeBondDump(timesteps []int, bonds [][2]int) string {
+	builder := strings.Builder{}
+	for _, timestep := range timesteps {
+		fmt.Fprintf(&builder, "ITEM: TIMESTEP\n%d\nITEM: NUMBER OF ENTRIES\n%d\n", timestep, len(bonds))
+		builder.WriteString("ITEM: BOX BOUNDS pp pp pp\n0 10\n0 10\n0 10\nITEM: ENTRIES index batom1 batom2\n")
+		for i, bond := range bonds {
+			fmt.Fprintf(&builder, "%d %d %d\n", i+1, bond[0], bond[1])
+		}
+	}
+	return builder.String()
+}
+
+func TestReadFrames(t *testing.T) {
+	// The data is a triangle, the bond dump adds the atom 4 to it
+	points := []*types.Point{{PointID: 0}, {PointID: 1}, {PointID: 2}}
+	edges := []*types.Edge{{Number: 0, Edge: [2]int{0, 1}}, {Number: 1, Edge: [2]int{1, 2}}, {Number: 2, Edge: [2]int{2, 0}}}
+	data := cycles_alg.MakeGraphJson(points, edges)
+	box := &types.Box{Hi: [3]float64{5, 5, 5}}
+	square := [][2]int{{1, 2}, {2, 3}, {3, 4}, {4, 1}}
+	tests := []struct {
+		name      string
+		data      *cycles_alg.GraphJson
+		atoms     string
+		bonds     string
+		timesteps []int
+		bondCount int
+		err       string
+	}{
+		{"paired dumps", nil, makeAtomDump([]int{0, 100}, 4), makeBondDump([]int{0, 100}, square), []int{0, 100}, 4, ""},
+		{"fixed bonds", data, makeAtomDump([]int{0, 100}, 3), "", []int{0, 100}, 3, ""},
+		{"fixed coordinates", data, "", makeBondDump([]int{5, 10}, square[:2]), []int{5, 10}, 2, ""},
+		{"bond dump ended", nil, makeAtomDump([]int{0, 100}, 4), makeBondDump([]int{0}, square), []int{0},
+			4, "timestep 100: the bond dump has ended"},
+		{"atom dump ended", nil, makeAtomDump([]int{0}, 4), makeBondDump([]int{0, 100}, square), []int{0},
+			4, "timestep 100: the atom dump has ended"},
+		{"timestep mismatch", nil, makeAtomDump([]int{0, 100}, 4), makeBondDump([]int{0, 50}, square), []int{0},
+			4, "timestep 100: the bond dump has the timestep 50 instead"},
+		{"atom count mismatch", data, makeAtomDump([]int{0}, 4), "", nil,
+			0, "timestep 0: expected 3 atoms as in the data file, got 4"},
+		{"self-loop", nil, makeAtomDump([]int{0}, 4), makeBondDump([]int{0}, [][2]int{{1, 2}, {3, 3}}), nil,
+			0, "timestep 0: bond 2, atom 3: self-loop"},
+	}
+	for _, test := range tests {
+		var atoms iter.Seq2[*trajectory.AtomFrame, error]
+		if len(test.atoms) != 0 {
+			atoms = trajectory.AtomFrames(strings.NewReader(test.atoms))
+		}
+		var bonds iter.Seq2[*trajectory.BondFrame, error]
+		if len(test.bonds) != 0 {
+			bonds = trajectory.BondFrames(strings.NewReader(test.bonds))
+		}
+		var timesteps []int
+		var err error
+		for f, frameErr := range readFrames(test.data, box, atoms, bonds) {
+			if frameErr != nil {
+				err = frameErr
+				break
+			}
+			timesteps = append(timesteps, f.timestep)
+			if len(f.graphJson.Edges) != test.bondCount {
+				t.Errorf("%s: expected %d bonds at the timestep %d, got: %d", test.name, test.bondCount, f.timestep, len(f.graphJson.Edges))
+			}
+			if len(test.atoms) == 0 && f.box != box {
+				t.Errorf("%s: expected the box of the data, got: %+v", test.name, f.box)
+			}
+		}
+		if !slices.Equal(timesteps, test.timesteps) {
+			t.Errorf("%s: expected the timesteps %v, got: %v", test.name, test.timesteps, timesteps)
+		}
+		if (err == nil) != (len(test.err) == 0) || (err != nil && err.Error() != test.err) {
+			t.Errorf("%s: expected the error %q, got: %v", test.name, test.err, err)
+		}
+	}
+}
+
+func TestParseBoundary(t *testing.T) {
+	tests := []struct {
+		boundary string
+		periodic [3]bool
+		ok       bool
+	}{
+		{"ppp", [3]bool{true, true, true}, true},
+		{"fff", [3]bool{}, true},
+		{"pps", [3]bool{true, true, false}, true},
+		{"fpm", [3]bool{false, true, false}, true},
+		{"pp", [3]bool{}, false},
+		{"ppx", [3]bool{}, false},
+		{"pppp", [3]bool{}, false},
+	}
+	for _, test := range tests {
+		periodic, err := parseBoundary(test.boundary)
+		if (err == nil) != test.ok || (test.ok && periodic != test.periodic) {
+			t.Errorf("%q: expected %v, %v, got: %v, %v", test.boundary, test.periodic, test.ok, periodic, err)
+		}
+	}
+}
+
+func TestParseBox(t *testing.T) {
+	box, err := parseBox(strings.Replace(testData, "0 10 zlo zhi", "-2.5 2.5 zlo zhi", 1), [3]bool{true, true, false})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := types.Box{Lo: [3]float64{0, 0, -2.5}, Hi: [3]float64{10, 10, 2.5}, Periodic: [3]bool{true, true, false}}
+	if *box != expected {
+		t.Errorf("Expected: %+v, got: %+v", expected, *box)
+	}
+
+	tests := []struct {
+		name     string
+		content  string
+		periodic [3]bool
+		err      string
+	}{
+		{"triclinic", strings.Replace(testData, "0 10 zlo zhi", "0 10 zlo zhi\n0 0 0 xy xz yz", 1),
+			[3]bool{true, true, true}, "triclinic boxes are not supported"},
+		{"missing bounds", strings.Replace(testData, "0 10 ylo yhi\n", "", 1),
+			[3]bool{true, true, true}, "could not find the ylo yhi line of the periodic box"},
+		{"bounds after the atoms", strings.Replace(testData, "0 10 xlo xhi\n", "", 1) + "\n0 10 xlo xhi\n",
+			[3]bool{true, false, false}, "could not find the xlo xhi line of the periodic box"},
+		{"wrong bound", strings.Replace(testData, "0 10 xlo xhi", "0 ten xlo xhi", 1),
+			[3]bool{true, true, true}, "strconv.ParseFloat: parsing \"ten\": invalid syntax"},
+	}
+	for _, test := range tests {
+		if _, err := parseBox(test.content, test.periodic); err == nil || err.Error() != test.err {
+			t.Errorf("%s: expected the error %q, got: %v", test.name, test.err, err)
+		}
+	}
+	if _, err := parseBox(strings.Replace(testData, "0 10 ylo yhi\n", "", 1), [3]bool{true, false, true}); err != nil {
+		t.Errorf("Expected the missing bounds of a non-periodic dimension to be skipped, got: %v", err)
+	}
+}
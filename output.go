@@ -0,0 +1,288 @@
+package main
+
+import (
+	"cycles/cycles_alg"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ring is a cycle as it is written by the machine-readable formats, the IDs
+// are the 1-based LAMMPS ones
+type ring struct {
+	Ring   int     `json:"ring"`
+	Size   int     `json:"size"`
+	Atoms  []int   `json:"atoms"`
+	Bonds  []int   `json:"bonds"`
+	Weight float64 `json:"weight"`
+	// Component is the 1-based connected component of the ring
+	Component int    `json:"component"`
+	Winding   [3]int `json:"winding"`
+	// Family is the 1-based unique ring family, set only by the modes that
+	// group the rings into families
+	Family     int `json:"family,omitempty"`
+	FamilySize int `json:"family_size,omitempty"`
+}
+
+type rings struct {
+	CyclomaticNumber int     `json:"cyclomatic_number"`
+	Components       int     `json:"components"`
+	TotalWeight      float64 `json:"total_weight"`
+	Rings            []ring  `json:"rings"`
+	// Cut are the 1-based numbers of the basis cycles larger than the
+	// maximum size
+	Cut []int `json:"cut,omitempty"`
+}
+
+func makeRing(cycle *cycles_alg.Cycle) ring {
+	atoms := cycle.GetAtomIDs()
+	bonds := cycle.GetBondIDs()
+	r := ring{
+		Ring:      cycle.Index + 1,
+		Size:      len(atoms),
+		Atoms:     atoms,
+		Bonds:     bonds,
+		Weight:    cycle.Weight,
+		Component: cycle.Component + 1,
+		Winding:   cycle.Winding,
+	}
+	if cycle.FamilySize > 0 {
+		r.Family, r.FamilySize = cycle.Family+1, cycle.FamilySize
+	}
+	return r
+}
+
+func makeRings(cycles []cycles_alg.Cycle) []ring {
+	result := make([]ring, len(cycles))
+	for i := range cycles {
+		result[i] = makeRing(&cycles[i])
+	}
+	return result
+}
+
+func writeResult(w io.Writer, result *cycles_alg.Result, format string, separateWinding bool) error {
+	switch format {
+	case "text":
+		if separateWinding {
+			contractible, winding := cycles_alg.SplitWindingCycles(result.Cycles)
+			printCycles(w, contractible, "C")
+			printCycles(w, winding, "W")
+		} else {
+			printCycles(w, result.Cycles, "C")
+		}
+		printComponents(w, result.Cycles)
+		if len(result.Cut) > 0 {
+			fmt.Fprintf(w, "Cut: %d basis cycles larger than the maximum size\n", len(result.Cut))
+		}
+		return nil
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(rings{
+			CyclomaticNumber: result.CyclomaticNumber,
+			Components:       result.ComponentsCount,
+			TotalWeight:      result.TotalWeight,
+			Rings:            makeRings(result.Cycles),
+			Cut:              getCutNumbers(result.Cut),
+		})
+	case "ndjson":
+		encoder := json.NewEncoder(w)
+		for _, ring := range makeRings(result.Cycles) {
+			if err := encoder.Encode(ring); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "csv":
+		return writeCSV(w, makeRings(result.Cycles))
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
+
+func getCutNumbers(cut []int) []int {
+	numbers := make([]int, len(cut))
+	for i, index := range cut {
+		numbers[i] = index + 1
+	}
+	return numbers
+}
+
+// writeCSV writes a row per ring member, the bond goes from the member to the
+// next one along the ring. The family columns are empty in the modes that do
+// not group the rings into families.
+func writeCSV(w io.Writer, rings []ring) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"ring", "size", "position", "atom", "bond", "weight", "component", "family", "family_size", "winding_x", "winding_y", "winding_z"})
+	for _, ring := range rings {
+		family, familySize := "", ""
+		if ring.FamilySize > 0 {
+			family, familySize = strconv.Itoa(ring.Family), strconv.Itoa(ring.FamilySize)
+		}
+		for i := range ring.Atoms {
+			writer.Write([]string{
+				strconv.Itoa(ring.Ring),
+				strconv.Itoa(ring.Size),
+				strconv.Itoa(i + 1),
+				strconv.Itoa(ring.Atoms[i]),
+				strconv.Itoa(ring.Bonds[i]),
+				strconv.FormatFloat(ring.Weight, 'g', -1, 64),
+				strconv.Itoa(ring.Component),
+				family,
+				familySize,
+				strconv.Itoa(ring.Winding[0]),
+				strconv.Itoa(ring.Winding[1]),
+				strconv.Itoa(ring.Winding[2]),
+			})
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func printCycles(w io.Writer, cycles []cycles_alg.Cycle, prefix string) {
+	for i, cycle := range cycles {
+		builder := strings.Builder{}
+		builder.WriteString(prefix)
+		builder.WriteString(strconv.Itoa(i))
+		builder.WriteString(": ")
+		for _, point := range cycle.Points {
+			builder.WriteString(fmt.Sprintf("%v, ", point))
+		}
+		if cycle.IsWinding() {
+			builder.WriteString(fmt.Sprintf("winding: %v", cycle.Winding))
+		}
+		builder.WriteString("\n\n")
+		fmt.Fprintln(w, builder.String())
+	}
+}
+
+func printComponents(w io.Writer, cycles []cycles_alg.Cycle) {
+	cyclesByComponent := cycles_alg.GetCyclesByComponent(cycles)
+	components := slices.Sorted(maps.Keys(cyclesByComponent))
+	for _, component := range components {
+		fmt.Fprintf(w, "Component %d: %d cycles\n", component, len(cyclesByComponent[component]))
+	}
+}
+
+func writeVerification(w io.Writer, verification *cycles_alg.Verification, result *cycles_alg.Result) {
+	checks := []struct {
+		name string
+		err  error
+	}{
+		{"simple cycles", verification.Simple},
+		{"independent", verification.Independent},
+		{"cyclomatic number", verification.Count},
+		{"minimal", verification.Minimal},
+	}
+	for _, check := range checks {
+		if check.err != nil {
+			fmt.Fprintf(w, "%-18s FAIL: %v\n", check.name, check.err)
+		} else {
+			fmt.Fprintf(w, "%-18s ok\n", check.name)
+		}
+	}
+	fmt.Fprintf(w, "\ncycles: %d\n", len(result.Cycles))
+	fmt.Fprintf(w, "total weight: %g\n", result.TotalWeight)
+	fmt.Fprintf(w, "reference weight: %g\n", verification.ReferenceWeight)
+}
+
+type stats struct {
+	Rings            int         `json:"rings"`
+	SizeCounts       map[int]int `json:"size_counts"`
+	MeanSize         float64     `json:"mean_size"`
+	MedianSize       float64     `json:"median_size"`
+	TotalWeight      float64     `json:"total_weight"`
+	CyclomaticNumber int         `json:"cyclomatic_number"`
+	Cut              int         `json:"cut,omitempty"`
+}
+
+func makeStats(s cycles_alg.Stats) stats {
+	return stats{
+		Rings:            s.RingsCount,
+		SizeCounts:       s.SizeCounts,
+		MeanSize:         s.MeanSize,
+		MedianSize:       s.MedianSize,
+		TotalWeight:      s.TotalWeight,
+		CyclomaticNumber: s.CyclomaticNumber,
+		Cut:              s.CutCount,
+	}
+}
+
+func writeStats(w io.Writer, s cycles_alg.Stats, format string) error {
+	switch format {
+	case "table":
+		fmt.Fprintf(w, "%-6s %s\n", "size", "count")
+		for _, size := range slices.Sorted(maps.Keys(s.SizeCounts)) {
+			fmt.Fprintf(w, "%-6d %d\n", size, s.SizeCounts[size])
+		}
+		fmt.Fprintf(w, "\nrings: %d\n", s.RingsCount)
+		fmt.Fprintf(w, "mean size: %.4g\n", s.MeanSize)
+		fmt.Fprintf(w, "median size: %g\n", s.MedianSize)
+		fmt.Fprintf(w, "total weight: %g\n", s.TotalWeight)
+		fmt.Fprintf(w, "cyclomatic number: %d\n", s.CyclomaticNumber)
+		if s.CutCount > 0 {
+			fmt.Fprintf(w, "cut: %d\n", s.CutCount)
+		}
+		return nil
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(makeStats(s))
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
+
+type frameStats struct {
+	Timestep int `json:"timestep"`
+	stats
+}
+
+// seriesWriter writes the stats of the frames of a trajectory as they come.
+// The csv format has a row per ring size of a frame, the json one is written
+// as a whole when the writer is closed.
+type seriesWriter struct {
+	w      io.Writer
+	format string
+	csv    *csv.Writer
+	frames []frameStats
+}
+
+func newSeriesWriter(w io.Writer, format string) *seriesWriter {
+	writer := &seriesWriter{w: w, format: format}
+	if format == "csv" {
+		writer.csv = csv.NewWriter(w)
+		writer.csv.Write([]string{"timestep", "size", "count"})
+	}
+	return writer
+}
+
+func (s *seriesWriter) write(timestep int, st cycles_alg.Stats) error {
+	switch s.format {
+	case "ndjson":
+		return json.NewEncoder(s.w).Encode(frameStats{timestep, makeStats(st)})
+	case "json":
+		s.frames = append(s.frames, frameStats{timestep, makeStats(st)})
+		return nil
+	case "csv":
+		for _, size := range slices.Sorted(maps.Keys(st.SizeCounts)) {
+			s.csv.Write([]string{strconv.Itoa(timestep), strconv.Itoa(size), strconv.Itoa(st.SizeCounts[size])})
+		}
+		s.csv.Flush()
+		return s.csv.Error()
+	}
+	return fmt.Errorf("unknown format %q", s.format)
+}
+
+func (s *seriesWriter) close() error {
+	if s.format != "json" {
+		return nil
+	}
+	encoder := json.NewEncoder(s.w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(s.frames)
+}
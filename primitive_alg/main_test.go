@@ -0,0 +1,182 @@
+package primitive_alg
+
+import (
+	"cycles/cycles_alg"
+	"cycles/types"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func makeGraphOfBonds(pointsCount int, bonds [][2]int) *cycles_alg.GraphJson {
+	points := make([]*types.Point, pointsCount)
+	for i := range points {
+		points[i] = types.NewPoint(i, 0, 0, 0)
+	}
+	edges := make([]*types.Edge, len(bonds))
+	for i, bond := range bonds {
+		edges[i] = &types.Edge{Number: i, Edge: bond}
+	}
+	return cycles_alg.MakeGraphJson(points, edges)
+}
+
+func getSizes(result *cycles_alg.Result) []int {
+	sizes := make([]int, len(result.Cycles))
+	for i, cycle := range result.Cycles {
+		sizes[i] = len(cycle.Points)
+	}
+	return sizes
+}
+
+// getRingKeys returns the sorted bond numbers of every ring
+func getRingKeys(result *cycles_alg.Result) []string {
+	keys := make([]string, len(result.Cycles))
+	for i, cycle := range result.Cycles {
+		bonds := make([]int, len(cycle.Edges))
+		for j, edge := range cycle.Edges {
+			bonds[j] = edge.Number
+		}
+		slices.Sort(bonds)
+		keys[i] = fmt.Sprint(bonds)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func TestCalculateRings(t *testing.T) {
+	tests := []struct {
+		name    string
+		points  int
+		bonds   [][2]int
+		maxSize int
+		sizes   []int
+	}{
+		// The squares of K4 have shortcuts
+		{"K4", 4, [][2]int{{0, 1}, {0, 2}, {0, 3}, {1, 2}, {1, 3}, {2, 3}}, 10, []int{3, 3, 3, 3}},
+		// The faces of the cube and the 6-rings around its body diagonals whose
+		// opposite corners are opposite in the cube too
+		{"cube", 8, [][2]int{
+			{0, 1}, {1, 2}, {2, 3}, {3, 0}, {4, 5}, {5, 6}, {6, 7}, {7, 4}, {0, 4}, {1, 5}, {2, 6}, {3, 7},
+		}, 10, []int{4, 4, 4, 4, 4, 4, 6, 6, 6, 6}},
+		{"prism", 6, [][2]int{
+			{0, 1}, {1, 2}, {2, 0}, {3, 4}, {4, 5}, {5, 3}, {0, 3}, {1, 4}, {2, 5},
+		}, 10, []int{3, 3, 4, 4, 4}},
+		// Naphthalene has no 10-ring since the shared bond is a shortcut
+		{"naphthalene", 10, [][2]int{
+			{0, 1}, {1, 2}, {2, 3}, {3, 4}, {4, 5}, {5, 0}, {4, 6}, {6, 7}, {7, 8}, {8, 9}, {9, 5},
+		}, 10, []int{6, 6}},
+		{"too small", 10, [][2]int{
+			{0, 1}, {1, 2}, {2, 3}, {3, 4}, {4, 5}, {5, 0}, {4, 6}, {6, 7}, {7, 8}, {8, 9}, {9, 5},
+		}, 5, []int{}},
+		// The three 4-rings of K2,3 are primitive, though any two of them
+		// make up the third one
+		{"K2,3", 5, [][2]int{{0, 2}, {0, 3}, {0, 4}, {1, 2}, {1, 3}, {1, 4}}, 10, []int{4, 4, 4}},
+	}
+	for _, test := range tests {
+		result, err := CalculateRings(makeGraphOfBonds(test.points, test.bonds), Options{MaxSize: test.maxSize})
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if sizes := getSizes(result); !slices.Equal(sizes, test.sizes) {
+			t.Errorf("%s: expected the ring sizes %v, got %v", test.name, test.sizes, sizes)
+		}
+		total := 0.0
+		for _, size := range test.sizes {
+			total += float64(size)
+		}
+		if result.TotalWeight != total {
+			t.Errorf("%s: expected the total weight %v, got %v", test.name, total, result.TotalWeight)
+		}
+	}
+
+	if _, err := CalculateRings(makeGraphOfBonds(3, [][2]int{{0, 1}, {1, 2}, {2, 0}}), Options{}); err == nil {
+		t.Errorf("expected an error without the maximum size")
+	}
+}
+
+// bruteForceRings checks every simple cycle of a small graph for shortcuts
+func bruteForceRings(pointsCount int, bonds [][2]int, maxSize int) []string {
+	distances := make([][]int, pointsCount)
+	for i := range distances {
+		distances[i] = make([]int, pointsCount)
+		for j := range distances[i] {
+			if i != j {
+				distances[i][j] = pointsCount
+			}
+		}
+	}
+	adjacent := make([][]int, pointsCount)
+	for i, bond := range bonds {
+		distances[bond[0]][bond[1]], distances[bond[1]][bond[0]] = 1, 1
+		adjacent[bond[0]] = append(adjacent[bond[0]], i)
+		adjacent[bond[1]] = append(adjacent[bond[1]], i)
+	}
+	for k := range pointsCount {
+		for i := range pointsCount {
+			for j := range pointsCount {
+				distances[i][j] = min(distances[i][j], distances[i][k]+distances[k][j])
+			}
+		}
+	}
+	keys := make([]string, 0)
+	seen := make(map[string]bool)
+	var walk func(points, ring []int)
+	walk = func(points, ring []int) {
+		last := points[len(points)-1]
+		for _, bond := range adjacent[last] {
+			next := bonds[bond][0] + bonds[bond][1] - last
+			if next == points[0] && len(points) >= 3 && !slices.Contains(ring, bond) {
+				isPrimitive := true
+				for i := range points {
+					for j := range points {
+						if distances[points[i]][points[j]] < min(abs(i-j), len(points)-abs(i-j)) {
+							isPrimitive = false
+						}
+					}
+				}
+				closed := append(slices.Clone(ring), bond)
+				slices.Sort(closed)
+				if key := fmt.Sprint(closed); isPrimitive && !seen[key] {
+					seen[key] = true
+					keys = append(keys, key)
+				}
+				continue
+			}
+			if next < points[0] || slices.Contains(points, next) || len(points) == maxSize {
+				continue
+			}
+			walk(append(points, next), append(ring, bond))
+		}
+	}
+	for start := range pointsCount {
+		walk([]int{start}, nil)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func TestCalculateRingsRandom(t *testing.T) {
+	random := rand.New(rand.NewPCG(19, 3))
+	for iteration := range 300 {
+		pointsCount := 4 + random.IntN(8)
+		probability := 0.2 + 0.4*random.Float64()
+		bonds := make([][2]int, 0)
+		for i := range pointsCount {
+			for j := i + 1; j < pointsCount; j++ {
+				if random.Float64() < probability {
+					bonds = append(bonds, [2]int{i, j})
+				}
+			}
+		}
+		maxSize := 3 + random.IntN(pointsCount-2)
+		result, err := CalculateRings(makeGraphOfBonds(pointsCount, bonds), Options{MaxSize: maxSize, Workers: 1 + iteration%3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := bruteForceRings(pointsCount, bonds, maxSize)
+		if keys := getRingKeys(result); !slices.Equal(keys, expected) {
+			t.Fatalf("bonds %v, maximum size %d: expected the rings %v, got %v", bonds, maxSize, expected, keys)
+		}
+	}
+}
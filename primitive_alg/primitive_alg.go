@@ -0,0 +1,132 @@
+// Package primitive_alg enumerates the primitive rings of a network, the
+// shortest-path rings of King and Guttman the ring statistics of amorphous
+// materials are based on.
+package primitive_alg
+
+import (
+	"cmp"
+	"cycles/cycles_alg"
+	"cycles/data_structs"
+	"cycles/types"
+	"fmt"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+type Options struct {
+	// Workers is the number of goroutines looking for the rings of the
+	// points, all the available CPUs are used when it is not positive.
+	Workers int
+	// MaxSize is the largest number of atoms of a ring, at least 3
+	MaxSize int
+	// Box makes the geometry periodic, the system is not periodic when it is
+	// nil
+	Box *types.Box
+	// ExcludeWinding leaves out the rings winding around the periodic box
+	ExcludeWinding bool
+}
+
+func (options *Options) getWorkers() int {
+	if options.Workers > 0 {
+		return options.Workers
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// CalculateRings finds all the primitive rings of at most MaxSize atoms. A
+// ring is primitive when no path between two of its atoms is shorter than
+// the ring between them, so that it can not be split into two smaller rings.
+// Unlike a minimum cycle basis the rings do not depend on a choice between
+// the rings of the same size. The rings are ordered by their sizes and their
+// weights are the sizes.
+func CalculateRings(graphJson *cycles_alg.GraphJson, options Options) (*cycles_alg.Result, error) {
+	if options.MaxSize < 3 {
+		return nil, fmt.Errorf("the maximum ring size is %d, it must be at least 3", options.MaxSize)
+	}
+	if err := graphJson.Validate(); err != nil {
+		return nil, err
+	}
+	if graphJson.Graph == nil {
+		graphJson = cycles_alg.MakeGraphJson(graphJson.Points, graphJson.Edges)
+	}
+	weights := make([]float64, len(graphJson.Edges))
+	for i := range weights {
+		weights[i] = 1
+	}
+	rings := getRings(graphJson.Graph, weights, options.MaxSize, options.getWorkers())
+	return cycles_alg.NewResult(graphJson, rings, cycles_alg.Options{
+		Weight:         cycles_alg.UnitWeight{},
+		Box:            options.Box,
+		ExcludeWinding: options.ExcludeWinding,
+	})
+}
+
+// getRings looks for the rings of every point on a pool of workers. The rings
+// of a point are the ones it is the largest point of, so every ring is found
+// once.
+func getRings(graph data_structs.Graph, weights []float64, maxSize int, workers int) [][]*types.Edge {
+	ringsOfPoints := make([][][]*types.Edge, len(graph))
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for range min(workers, len(graph)) {
+		wg.Go(func() {
+			paths := data_structs.NewShortestPaths(graph, weights)
+			shortcuts := data_structs.NewShortestPaths(graph, weights)
+			for root := int(next.Add(1) - 1); root < len(graph); root = int(next.Add(1) - 1) {
+				ringsOfPoints[root] = getRingsOfRoot(paths, shortcuts, root, maxSize)
+			}
+		})
+	}
+	wg.Wait()
+	rings := slices.Concat(ringsOfPoints...)
+	slices.SortStableFunc(rings, func(r1, r2 []*types.Edge) int {
+		return cmp.Compare(len(r1), len(r2))
+	})
+	return rings
+}
+
+// getRingsOfRoot returns the primitive rings the root is the largest point
+// of. The paths along such a ring from the root are the shortest ones and
+// pass through the smaller points, so the ring is a cycle of a family of the
+// root.
+func getRingsOfRoot(paths, shortcuts *data_structs.ShortestPaths, root, maxSize int) [][]*types.Edge {
+	rings := make([][]*types.Edge, 0)
+	paths.Calculate(root, float64(maxSize/2))
+	for _, f := range paths.GetFamilies(float64(maxSize)) {
+		for ring := range paths.GetCycles(f) {
+			if isPrimitive(shortcuts, ring, root) {
+				rings = append(rings, ring)
+			}
+		}
+	}
+	return rings
+}
+
+// isPrimitive looks for a shortcut between the points of the ring that starts
+// and ends at the root. The root has none, its paths are the shortest ones.
+func isPrimitive(shortcuts *data_structs.ShortestPaths, ring []*types.Edge, root int) bool {
+	points := make([]int, len(ring))
+	points[0] = root
+	for i := 1; i < len(ring); i++ {
+		points[i] = ring[i-1].GetOtherSide(points[i-1])
+	}
+	for i := 1; i < len(points); i++ {
+		shortcuts.Calculate(points[i], float64(len(ring)/2))
+		for j := range points {
+			distance := min(abs(i-j), len(ring)-abs(i-j))
+			if shortcuts.GetLength(points[j]) < float64(distance) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"cycles/cycles_alg"
+	"cycles/trajectory"
+	"cycles/types"
+	"flag"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"slices"
+)
+
+// frame is a graph of the trajectory ready for the algorithm
+type frame struct {
+	timestep  int
+	graphJson *cycles_alg.GraphJson
+	box       *types.Box
+}
+
+var trajectoryFormats = []string{"ndjson", "json", "csv"}
+
+// runTrajectory calculates the basis of every frame of a trajectory and
+// reports the ring size distribution over time. The coordinates come from the
+// atom dump and the bonds from the local dump, the data file gives the ones
+// that do not change. It returns the exit status as runCycles does.
+func runTrajectory(args []string, stderr io.Writer) int {
+	c := config{}
+	flags := flag.NewFlagSet(os.Args[0]+" trajectory", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	c.addFlags(flags, trajectoryFormats)
+	dumpPtr := flags.String("dump", "", "Specifies the atom dump with the coordinates of every frame, its box replaces the boundary parameter")
+	bondsPtr := flags.String("bonds", "", "Specifies the local dump with the batom1, batom2 and optional btype columns giving the bonds of every frame")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if !slices.Contains(trajectoryFormats, c.format) {
+		fmt.Fprintln(stderr, "Wrong usage of the format parameter")
+		return 2
+	}
+	if len(*dumpPtr) == 0 && len(*bondsPtr) == 0 {
+		fmt.Fprintln(stderr, "The trajectory mode requires the dump or the bonds parameter")
+		return 2
+	}
+	if err := c.calculateTrajectory(*dumpPtr, *bondsPtr); err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+	return 0
+}
+
+// calculateTrajectory writes the stats of the frames of the dumps, an empty
+// path leaves the dump out.
+func (c *config) calculateTrajectory(dumpPath, bondsPath string) error {
+	options, err := c.getOptions()
+	if err != nil {
+		return err
+	}
+	var data *cycles_alg.GraphJson
+	var box *types.Box
+	if len(dumpPath) == 0 || len(bondsPath) == 0 {
+		if data, box, err = c.readData(); err != nil {
+			return err
+		}
+	}
+	var atoms iter.Seq2[*trajectory.AtomFrame, error]
+	if len(dumpPath) != 0 {
+		file, err := os.Open(dumpPath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		atoms = trajectory.AtomFrames(file)
+	}
+	var bonds iter.Seq2[*trajectory.BondFrame, error]
+	if len(bondsPath) != 0 {
+		file, err := os.Open(bondsPath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		bonds = trajectory.BondFrames(file)
+	}
+
+	return c.writeOutput(func(w io.Writer) error {
+		writer := newSeriesWriter(w, c.format)
+		for f, err := range readFrames(data, box, atoms, bonds) {
+			if err != nil {
+				return err
+			}
+			options.Box = f.box
+			result, err := c.calculateCyclesOfGraph(f.graphJson, options)
+			if err != nil {
+				return fmt.Errorf("timestep %d: %w", f.timestep, err)
+			}
+			if err := writer.write(f.timestep, result.GetStats()); err != nil {
+				return err
+			}
+		}
+		return writer.close()
+	})
+}
+
+// readFrames pairs the frames of the atom and the bond dumps. A missing dump
+// is replaced by the data: the fixed coordinates with the box or the fixed
+// bonds.
+func readFrames(
+	data *cycles_alg.GraphJson,
+	box *types.Box,
+	atoms iter.Seq2[*trajectory.AtomFrame, error],
+	bonds iter.Seq2[*trajectory.BondFrame, error],
+) iter.Seq2[*frame, error] {
+	return func(yield func(*frame, error) bool) {
+		var nextAtoms func() (*trajectory.AtomFrame, error, bool)
+		if atoms != nil {
+			next, stop := iter.Pull2(atoms)
+			defer stop()
+			nextAtoms = next
+		}
+		var nextBonds func() (*trajectory.BondFrame, error, bool)
+		if bonds != nil {
+			next, stop := iter.Pull2(bonds)
+			defer stop()
+			nextBonds = next
+		}
+		for {
+			f := &frame{box: box}
+			var points []*types.Point
+			var edges []*types.Edge
+			if data != nil {
+				points, edges = data.Points, data.Edges
+			}
+			if nextAtoms != nil {
+				atomFrame, err, ok := nextAtoms()
+				if !ok {
+					if nextBonds != nil {
+						if bondFrame, err, ok := nextBonds(); err != nil {
+							yield(nil, err)
+						} else if ok {
+							yield(nil, fmt.Errorf("timestep %d: the atom dump has ended", bondFrame.Timestep))
+						}
+					}
+					return
+				}
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+				f.timestep, f.box, points = atomFrame.Timestep, atomFrame.Box, atomFrame.Points
+			}
+			if nextBonds != nil {
+				bondFrame, err, ok := nextBonds()
+				if !ok {
+					if nextAtoms != nil {
+						yield(nil, fmt.Errorf("timestep %d: the bond dump has ended", f.timestep))
+					}
+					return
+				}
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+				if nextAtoms == nil {
+					f.timestep = bondFrame.Timestep
+				} else if bondFrame.Timestep != f.timestep {
+					yield(nil, fmt.Errorf("timestep %d: the bond dump has the timestep %d instead", f.timestep, bondFrame.Timestep))
+					return
+				}
+				edges = bondFrame.Edges
+			}
+			if data != nil && nextBonds == nil && len(points) != len(data.Points) {
+				yield(nil, fmt.Errorf("timestep %d: expected %d atoms as in the data file, got %d", f.timestep, len(data.Points), len(points)))
+				return
+			}
+			if err := cycles_alg.NewGraphJson(points, edges, nil).Validate(); err != nil {
+				yield(nil, fmt.Errorf("timestep %d: %w", f.timestep, err))
+				return
+			}
+			f.graphJson = cycles_alg.MakeGraphJson(points, edges)
+			if !yield(f, nil) {
+				return
+			}
+		}
+	}
+}
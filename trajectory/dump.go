@@ -0,0 +1,183 @@
+// Package trajectory reads LAMMPS dump files frame by frame.
+package trajectory
+
+import (
+	"bufio"
+	"cycles/types"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"strconv"
+	"strings"
+)
+
+// section is a frame of a dump file as it is written: the timestep, the box
+// and a table of atoms or local entries
+type section struct {
+	timestep int
+	box      *types.Box
+	columns  []string
+	rows     [][]string
+}
+
+func (s *section) getColumn(names ...string) int {
+	for _, name := range names {
+		for i, column := range s.columns {
+			if column == name {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+type dumpReader struct {
+	scanner    *bufio.Scanner
+	lineNumber int
+}
+
+func (r *dumpReader) readLine() (string, error) {
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	r.lineNumber++
+	return r.scanner.Text(), nil
+}
+
+func (r *dumpReader) errorf(format string, args ...any) error {
+	return fmt.Errorf("line %d: %s", r.lineNumber, fmt.Sprintf(format, args...))
+}
+
+// readSection reads the next frame, io.EOF means there are no frames left
+func (r *dumpReader) readSection() (*section, error) {
+	s := &section{}
+	count := -1
+	for {
+		line, err := r.readLine()
+		if err == io.EOF && count < 0 && s.columns == nil && s.box == nil {
+			return nil, io.EOF
+		}
+		if err == io.EOF {
+			return nil, r.errorf("unexpected end of the frame")
+		}
+		if err != nil {
+			return nil, err
+		}
+		item, ok := strings.CutPrefix(strings.TrimSpace(line), "ITEM:")
+		if !ok {
+			if len(strings.TrimSpace(line)) == 0 {
+				continue
+			}
+			return nil, r.errorf("expected an ITEM line, got %q", line)
+		}
+		item = strings.TrimSpace(item)
+		switch {
+		case item == "TIMESTEP":
+			if s.timestep, err = r.readInt(); err != nil {
+				return nil, err
+			}
+		case item == "NUMBER OF ATOMS" || item == "NUMBER OF ENTRIES":
+			if count, err = r.readInt(); err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(item, "BOX BOUNDS"):
+			if s.box, err = r.readBox(strings.Fields(strings.TrimPrefix(item, "BOX BOUNDS"))); err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(item, "ATOMS") || strings.HasPrefix(item, "ENTRIES"):
+			if count < 0 {
+				return nil, r.errorf("the number of rows is missing before %q", item)
+			}
+			s.columns = strings.Fields(item)[1:]
+			s.rows = make([][]string, count)
+			for i := range s.rows {
+				line, err := r.readLine()
+				if err == io.EOF {
+					return nil, r.errorf("expected %d rows, got %d", count, i)
+				}
+				if err != nil {
+					return nil, err
+				}
+				s.rows[i] = strings.Fields(line)
+				if len(s.rows[i]) != len(s.columns) {
+					return nil, r.errorf("expected %d columns, got %d", len(s.columns), len(s.rows[i]))
+				}
+			}
+			return s, nil
+		default:
+			// Skip the items this package does not know like UNITS or TIME
+			if _, err := r.readLine(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func (r *dumpReader) readInt() (int, error) {
+	line, err := r.readLine()
+	if err == io.EOF {
+		return 0, r.errorf("unexpected end of the file")
+	}
+	if err != nil {
+		return 0, err
+	}
+	value, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil {
+		return 0, r.errorf("%v", err)
+	}
+	return value, nil
+}
+
+// readBox reads the bounds after the "ITEM: BOX BOUNDS pp pp ff" line, the
+// styles are the LAMMPS boundary ones per dimension.
+func (r *dumpReader) readBox(styles []string) (*types.Box, error) {
+	if len(styles) > 0 && styles[0] == "xy" {
+		return nil, errors.New("triclinic boxes are not supported")
+	}
+	box := &types.Box{}
+	for i, style := range styles {
+		if i < 3 {
+			box.Periodic[i] = style == "pp"
+		}
+	}
+	for i := range 3 {
+		line, err := r.readLine()
+		if err == io.EOF {
+			return nil, r.errorf("unexpected end of the box bounds")
+		}
+		if err != nil {
+			return nil, err
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, r.errorf("expected the lower and the upper bounds, got %q", line)
+		}
+		if box.Lo[i], err = strconv.ParseFloat(fields[0], 64); err != nil {
+			return nil, r.errorf("%v", err)
+		}
+		if box.Hi[i], err = strconv.ParseFloat(fields[1], 64); err != nil {
+			return nil, r.errorf("%v", err)
+		}
+	}
+	return box, nil
+}
+
+func readSections(r io.Reader) iter.Seq2[*section, error] {
+	return func(yield func(*section, error) bool) {
+		reader := &dumpReader{scanner: bufio.NewScanner(r)}
+		reader.scanner.Buffer(nil, 1<<20)
+		for {
+			s, err := reader.readSection()
+			if err == io.EOF {
+				return
+			}
+			if !yield(s, err) || err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package trajectory
+
+import (
+	"cycles/types"
+	"fmt"
+	"io"
+	"iter"
+	"strconv"
+)
+
+// AtomFrame is a frame of an atom dump. The point i is the atom with the ID
+// i+1, so the IDs must go from 1 to the number of atoms.
+type AtomFrame struct {
+	Timestep int
+	Points   []*types.Point
+	Box      *types.Box
+}
+
+// BondFrame is a frame of a local dump of bonds. The bond i gets the number i.
+type BondFrame struct {
+	Timestep int
+	Edges    []*types.Edge
+	Box      *types.Box
+}
+
+// AtomFrames reads an atom dump with the id column and the x y z, xu yu zu or
+// xs ys zs coordinates.
+func AtomFrames(r io.Reader) iter.Seq2[*AtomFrame, error] {
+	return func(yield func(*AtomFrame, error) bool) {
+		for s, err := range readSections(r) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			frame, err := makeAtomFrame(s)
+			if !yield(frame, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// BondFrames reads a local dump of bonds like the one of
+//
+//	compute 1 all property/local btype batom1 batom2
+//	dump 1 all local 100 bonds.dump c_1[1] c_1[2] c_1[3]
+//	dump_modify 1 colname 1 btype colname 2 batom1 colname 3 batom2
+//
+// The btype column is optional, bonds of the type 0 are broken and skipped.
+// A bond listed twice is kept once.
+func BondFrames(r io.Reader) iter.Seq2[*BondFrame, error] {
+	return func(yield func(*BondFrame, error) bool) {
+		for s, err := range readSections(r) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			frame, err := makeBondFrame(s)
+			if !yield(frame, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+var coordinateColumns = [3][]string{
+	{"x", "xu", "xs", "xsu"},
+	{"y", "yu", "ys", "ysu"},
+	{"z", "zu", "zs", "zsu"},
+}
+
+func makeAtomFrame(s *section) (*AtomFrame, error) {
+	idColumn := s.getColumn("id")
+	if idColumn < 0 {
+		return nil, fmt.Errorf("timestep %d: the id column is missing", s.timestep)
+	}
+	if s.box == nil {
+		return nil, fmt.Errorf("timestep %d: the box bounds are missing", s.timestep)
+	}
+	columns := [3]int{}
+	scaled := [3]bool{}
+	for i, names := range coordinateColumns {
+		if columns[i] = s.getColumn(names...); columns[i] < 0 {
+			return nil, fmt.Errorf("timestep %d: the %s column is missing", s.timestep, names[0])
+		}
+		scaled[i] = s.columns[columns[i]] == names[2] || s.columns[columns[i]] == names[3]
+	}
+	lengths := s.box.GetLengths()
+	frame := &AtomFrame{Timestep: s.timestep, Points: make([]*types.Point, len(s.rows)), Box: s.box}
+	for _, row := range s.rows {
+		id, err := strconv.Atoi(row[idColumn])
+		if err != nil {
+			return nil, fmt.Errorf("timestep %d: %w", s.timestep, err)
+		}
+		if id < 1 || id > len(frame.Points) || frame.Points[id-1] != nil {
+			return nil, fmt.Errorf("timestep %d: the atom IDs must go from 1 to %d, got %d", s.timestep, len(frame.Points), id)
+		}
+		position := types.Vector{}
+		for i, column := range columns {
+			if position[i], err = strconv.ParseFloat(row[column], 64); err != nil {
+				return nil, fmt.Errorf("timestep %d: %w", s.timestep, err)
+			}
+			if scaled[i] {
+				position[i] = s.box.Lo[i] + position[i]*lengths[i]
+			}
+		}
+		frame.Points[id-1] = types.NewPoint(id-1, position[0], position[1], position[2])
+	}
+	return frame, nil
+}
+
+func makeBondFrame(s *section) (*BondFrame, error) {
+	atomColumns := [2]int{s.getColumn("batom1"), s.getColumn("batom2")}
+	if atomColumns[0] < 0 || atomColumns[1] < 0 {
+		return nil, fmt.Errorf("timestep %d: the batom1 and batom2 columns are missing", s.timestep)
+	}
+	typeColumn := s.getColumn("btype")
+	frame := &BondFrame{Timestep: s.timestep, Edges: make([]*types.Edge, 0, len(s.rows)), Box: s.box}
+	seen := make(map[[2]int]bool, len(s.rows))
+	for _, row := range s.rows {
+		bondType := 1
+		if typeColumn >= 0 {
+			var err error
+			if bondType, err = strconv.Atoi(row[typeColumn]); err != nil {
+				return nil, fmt.Errorf("timestep %d: %w", s.timestep, err)
+			}
+			if bondType == 0 {
+				continue
+			}
+		}
+		ends := [2]int{}
+		for i, column := range atomColumns {
+			id, err := strconv.Atoi(row[column])
+			if err != nil {
+				return nil, fmt.Errorf("timestep %d: %w", s.timestep, err)
+			}
+			ends[i] = id - 1
+		}
+		key := [2]int{min(ends[0], ends[1]), max(ends[0], ends[1])}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		frame.Edges = append(frame.Edges, &types.Edge{
+			Number: len(frame.Edges),
+			Edge:   ends,
+			Type:   bondType,
+		})
+	}
+	return frame, nil
+}
@@ -0,0 +1,103 @@
+package trajectory
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+const atomDump = `ITEM: TIMESTEP
+0
+ITEM: NUMBER OF ATOMS
+3
+ITEM: BOX BOUNDS pp pp ff
+0.0 10.0
+0.0 10.0
+-5.0 5.0
+ITEM: ATOMS id type xs ys z
+2 1 0.2 0.1 0.0
+1 1 0.1 0.1 0.0
+3 1 0.1 0.2 1.0
+ITEM: TIMESTEP
+100
+ITEM: NUMBER OF ATOMS
+3
+ITEM: BOX BOUNDS pp pp ff
+0.0 10.0
+0.0 10.0
+-5.0 5.0
+ITEM: ATOMS id type xs ys z
+1 1 0.1 0.1 0.0
+2 1 0.2 0.1 0.0
+3 1 0.1 0.3 1.0
+`
+
+const bondDump = `ITEM: TIMESTEP
+0
+ITEM: NUMBER OF ENTRIES
+3
+ITEM: BOX BOUNDS pp pp ff
+0.0 10.0
+0.0 10.0
+-5.0 5.0
+ITEM: ENTRIES index btype batom1 batom2
+1 1 1 2
+2 0 2 3
+3 2 2 1
+`
+
+func TestAtomFrames(t *testing.T) {
+	timesteps := []int{}
+	for frame, err := range AtomFrames(strings.NewReader(atomDump)) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		timesteps = append(timesteps, frame.Timestep)
+		if frame.Box.Periodic != [3]bool{true, true, false} || frame.Box.Lo[2] != -5 {
+			t.Errorf("Wrong box: %+v", frame.Box)
+		}
+		if len(frame.Points) != 3 || frame.Points[1].PointID != 1 || frame.Points[1].X != 2 || frame.Points[1].Y != 1 {
+			t.Errorf("Expected the atom 2 at (2, 1, 0), got: %v", frame.Points[1])
+		}
+	}
+	if !slices.Equal(timesteps, []int{0, 100}) {
+		t.Errorf("Expected the timesteps 0 and 100, got: %v", timesteps)
+	}
+}
+
+func TestAtomFramesErrors(t *testing.T) {
+	for _, dump := range []string{
+		strings.Replace(atomDump, "2 1 0.2 0.1 0.0", "4 1 0.2 0.1 0.0", 1),
+		strings.Replace(atomDump, "NUMBER OF ATOMS\n3", "NUMBER OF ATOMS\n4", 1),
+		strings.Replace(atomDump, "xs ys z", "xs ys q", 1),
+	} {
+		failed := false
+		for _, err := range AtomFrames(strings.NewReader(dump)) {
+			if err != nil {
+				failed = true
+			}
+		}
+		if !failed {
+			t.Errorf("Expected an error for the dump:\n%s", dump)
+		}
+	}
+}
+
+func TestBondFrames(t *testing.T) {
+	frames := 0
+	for frame, err := range BondFrames(strings.NewReader(bondDump)) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		frames++
+		if len(frame.Edges) != 1 {
+			t.Fatalf("Expected the broken and the repeated bonds to be skipped, got: %d bonds", len(frame.Edges))
+		}
+		if edge := frame.Edges[0]; edge.Number != 0 || edge.Edge != [2]int{0, 1} || edge.Type != 1 {
+			t.Errorf("Wrong bond: %+v", edge)
+		}
+	}
+	if frames != 1 {
+		t.Errorf("Expected 1 frame, got: %d", frames)
+	}
+}
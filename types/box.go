@@ -0,0 +1,42 @@
+package types
+
+import "math"
+
+// Box is an orthogonal simulation box, the coordinates along the periodic
+// dimensions wrap around it.
+type Box struct {
+	Lo, Hi   Vector
+	Periodic [3]bool
+}
+
+func NewBox(lo, hi Vector) *Box {
+	return &Box{
+		Lo:       lo,
+		Hi:       hi,
+		Periodic: [3]bool{true, true, true},
+	}
+}
+
+func (box *Box) GetLengths() Vector {
+	return box.Hi.Sub(box.Lo)
+}
+
+// GetDisplacement returns the minimum image of the vector from one point to
+// the other. A nil box is not periodic.
+func (box *Box) GetDisplacement(from, to *Point) Vector {
+	displacement := to.GetPosition().Sub(from.GetPosition())
+	if box == nil {
+		return displacement
+	}
+	lengths := box.GetLengths()
+	for i := range displacement {
+		if box.Periodic[i] && lengths[i] > 0 {
+			displacement[i] -= lengths[i] * math.Round(displacement[i]/lengths[i])
+		}
+	}
+	return displacement
+}
+
+func (box *Box) GetDistance(from, to *Point) float64 {
+	return box.GetDisplacement(from, to).Len()
+}
@@ -1,22 +1,34 @@
 package types
 
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrEdgeMismatch is the error of two edges with the same number that connect
+// different points
+var ErrEdgeMismatch = errors.New("two edges with equal numbers but different points")
+
 type Edge struct {
 	Number int
 	Edge   [2]int
+	Type   int
 	State  State
 }
 
-func (edge *Edge) Equals(other *Edge) bool {
+// Equals compares the numbers of the edges, the edges of the same number must
+// connect the same points or ErrEdgeMismatch is returned.
+func (edge *Edge) Equals(other *Edge) (bool, error) {
 	if other == nil || edge.Number != other.Number {
-		return false
+		return false, nil
 	}
 	if (edge.Edge[0] == other.Edge[0] &&
 		edge.Edge[1] == other.Edge[1]) ||
 		(edge.Edge[0] == other.Edge[1] &&
 			edge.Edge[1] == other.Edge[0]) {
-		return true
+		return true, nil
 	} else {
-		panic("Two edges with equal numbers but different points")
+		return false, fmt.Errorf("%w: %d %v and %v", ErrEdgeMismatch, edge.Number, edge.Edge, other.Edge)
 	}
 }
 
@@ -1,7 +1,15 @@
 package types
 
+import "errors"
+
+// ErrEmptyPath is the error of taking an edge of an empty path
+var ErrEmptyPath = errors.New("the path is empty")
+
 type Path []*Edge
 
-func (path *Path) Last() *Edge {
-	return (*path)[len(*path)-1]
+func (path *Path) Last() (*Edge, error) {
+	if len(*path) == 0 {
+		return nil, ErrEmptyPath
+	}
+	return (*path)[len(*path)-1], nil
 }
@@ -10,9 +10,13 @@ type Point struct {
 
 func (point *Point) GetDistanceTo(other *Point) float64 {
 	return math.Sqrt(
-		(point.X-other.X)*(point.X*other.X) +
-			(point.Y-other.Y)*(point.Y*other.Y) +
-			(point.Z-other.Z)*(point.Z*other.Z))
+		(point.X-other.X)*(point.X-other.X) +
+			(point.Y-other.Y)*(point.Y-other.Y) +
+			(point.Z-other.Z)*(point.Z-other.Z))
+}
+
+func (point *Point) GetPosition() Vector {
+	return Vector{point.X, point.Y, point.Z}
 }
 
 func NewPoint(pointID int, X, Y, Z float64) *Point {
@@ -1,14 +1,59 @@
 package types
 
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+const wordSize = 64
+
+// ErrSizeMismatch is the error of the operations on the support vectors of
+// different sizes
+var ErrSizeMismatch = errors.New("support vectors must be of the same size")
+
+// SupportVector is a GF(2) vector over the edges packed into 64-bit words:
+// the bit of the edge number n is the bit n%64 of the word n/64.
 type SupportVector []uint64
 
-func (s SupportVector) GetScalarMultiplication(other SupportVector) uint64 {
+func NewSupportVector(size int) SupportVector {
+	return make(SupportVector, (size+wordSize-1)/wordSize)
+}
+
+func (s SupportVector) Set(edgeNumber int) {
+	s[edgeNumber/wordSize] |= 1 << (edgeNumber % wordSize)
+}
+
+func (s SupportVector) Test(edgeNumber int) bool {
+	return s[edgeNumber/wordSize]&(1<<(edgeNumber%wordSize)) != 0
+}
+
+// GetScalarMultiplication returns the number of edges set in both vectors,
+// so its parity is the GF(2) scalar product.
+func (s SupportVector) GetScalarMultiplication(other SupportVector) (uint64, error) {
 	if len(s) != len(other) {
-		panic("Support vectors must be of the same size")
+		return 0, fmt.Errorf("%w: %d and %d words", ErrSizeMismatch, len(s), len(other))
 	}
 	var res uint64 = 0
 	for i := range s {
-		res += s[i] * other[i]
+		res += uint64(bits.OnesCount64(s[i] & other[i]))
+	}
+	return res, nil
+}
+
+func (s SupportVector) GetParity() uint64 {
+	var parity uint64 = 0
+	for _, word := range s {
+		parity ^= uint64(bits.OnesCount64(word))
+	}
+	return parity & 1
+}
+
+func (s SupportVector) AND(other SupportVector) SupportVector {
+	res := make(SupportVector, len(s))
+	for i := range s {
+		res[i] = s[i] & other[i]
 	}
 	return res
 }
@@ -20,3 +65,35 @@ func (s SupportVector) XOR(other SupportVector) SupportVector {
 	}
 	return res
 }
+
+func (s SupportVector) ORInPlace(other SupportVector) {
+	for i := range s {
+		s[i] |= other[i]
+	}
+}
+
+func (s SupportVector) XORInPlace(other SupportVector) {
+	for i := range s {
+		s[i] ^= other[i]
+	}
+}
+
+// GetFirst returns the smallest edge number set in the vector or -1 for the
+// zero vector.
+func (s SupportVector) GetFirst() int {
+	for i, word := range s {
+		if word != 0 {
+			return i*wordSize + bits.TrailingZeros64(word)
+		}
+	}
+	return -1
+}
+
+// Key packs the words into a string, so that the vectors can be map keys
+func (s SupportVector) Key() string {
+	bytes := make([]byte, 0, len(s)*8)
+	for _, word := range s {
+		bytes = binary.LittleEndian.AppendUint64(bytes, word)
+	}
+	return string(bytes)
+}
@@ -0,0 +1,17 @@
+package types
+
+import "math"
+
+type Vector [3]float64
+
+func (v Vector) Add(other Vector) Vector {
+	return Vector{v[0] + other[0], v[1] + other[1], v[2] + other[2]}
+}
+
+func (v Vector) Sub(other Vector) Vector {
+	return Vector{v[0] - other[0], v[1] - other[1], v[2] - other[2]}
+}
+
+func (v Vector) Len() float64 {
+	return math.Sqrt(v[0]*v[0] + v[1]*v[1] + v[2]*v[2])
+}